@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+type Limits struct {
+	// MaxEntries is the most entries, including directories and figures, an
+	// archive may hold.
+	MaxEntries int
+	// MaxFileSize is the largest a single source file may be once unpacked.
+	MaxFileSize int64
+	// MaxTotalSize is the most that may be unpacked from an archive in total.
+	MaxTotalSize int64
+}
+
+var DefaultLimits = Limits{
+	MaxEntries:   2000,
+	MaxFileSize:  5 << 20,
+	MaxTotalSize: 50 << 20,
+}
+
+// sourceExtensions are the files read from an archive. Anything else, such as
+// figures, is skipped without being unpacked.
+var sourceExtensions = map[string]bool{
+	".tex": true,
+	".bib": true,
+}
+
+// ReadZip unpacks the LaTeX sources in a zip archive in memory, returning
+// their contents by their path in the archive.
+func ReadZip(data []byte, limits Limits) (map[string]string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid zip archive: %w", err)
+	}
+	if len(reader.File) > limits.MaxEntries {
+		return nil, fmt.Errorf("archive has %d entries, more than the limit of %d", len(reader.File), limits.MaxEntries)
+	}
+
+	files := make(map[string]string)
+	var total int64
+	for _, file := range reader.File {
+		// entries that are skipped are never unpacked, so their names are not
+		// checked
+		if file.FileInfo().IsDir() || !sourceExtensions[strings.ToLower(path.Ext(file.Name))] {
+			continue
+		}
+		name, err := cleanName(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if isMetadata(name) {
+			continue
+		}
+		if _, ok := files[name]; ok {
+			return nil, fmt.Errorf("archive has more than one entry named %s", name)
+		}
+
+		content, err := readFile(file, limits.MaxFileSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		total += int64(len(content))
+		if total > limits.MaxTotalSize {
+			return nil, fmt.Errorf("archive unpacks to more than the limit of %d bytes", limits.MaxTotalSize)
+		}
+		files[name] = content
+	}
+	return files, nil
+}
+
+// cleanName normalises the name of an entry, rejecting names which would
+// escape the root of the archive, including those starting with a Windows
+// drive letter such as C:.
+func cleanName(name string) (string, error) {
+	name = strings.ReplaceAll(name, `\`, "/")
+	hasDrive := len(name) >= 2 && name[1] == ':' && ((name[0] >= 'a' && name[0] <= 'z') || (name[0] >= 'A' && name[0] <= 'Z'))
+	if strings.HasPrefix(name, "/") || hasDrive {
+		return "", fmt.Errorf("archive entry %s has an absolute path", name)
+	}
+	cleaned := path.Clean(name)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("archive entry %s is outside the archive", name)
+	}
+	return cleaned, nil
+}
+
+// isMetadata reports files added by operating systems, such as the resource
+// forks macOS adds under __MACOSX.
+func isMetadata(name string) bool {
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(path.Base(name), "._")
+}
+
+// readFile reads a file from the archive, without trusting the size recorded
+// in its header.
+func readFile(file *zip.File, maxSize int64) (string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+	content, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+	if err != nil {
+		return "", err
+	}
+	if int64(len(content)) > maxSize {
+		return "", fmt.Errorf("file is larger than the limit of %d bytes", maxSize)
+	}
+	return string(content), nil
+}
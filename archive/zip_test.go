@@ -0,0 +1,116 @@
+package archive
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func makeZip(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if _, err := file.Write([]byte(content)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadZip(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		limits   Limits
+		expected []string
+		err      string
+	}{
+		{
+			name: "Sources are read, figures and metadata are skipped",
+			files: map[string]string{
+				"paper/main.tex":             "\\documentclass{jacow}",
+				"paper/refs.bib":             "@article{a}",
+				"paper/figure.png":           "\x89PNG",
+				"__MACOSX/paper/._main.tex":  "",
+				"paper\\sections\\intro.tex": "Introduction",
+				"paper/figures/plot:1.png":   "\x89PNG",
+				"paper/notes: draft.tex":     "Notes",
+			},
+			limits:   DefaultLimits,
+			expected: []string{"paper/main.tex", "paper/refs.bib", "paper/sections/intro.tex", "paper/notes: draft.tex"},
+		},
+		{
+			name:   "Path traversal",
+			files:  map[string]string{"paper/../../main.tex": ""},
+			limits: DefaultLimits,
+			err:    "outside the archive",
+		},
+		{
+			name:   "Absolute path",
+			files:  map[string]string{"/etc/main.tex": ""},
+			limits: DefaultLimits,
+			err:    "absolute path",
+		},
+		{
+			name:   "Drive letter",
+			files:  map[string]string{"C:\\paper\\main.tex": ""},
+			limits: DefaultLimits,
+			err:    "absolute path",
+		},
+		{
+			name:     "Absolute path of a skipped file",
+			files:    map[string]string{"/etc/figure.png": "", "main.tex": ""},
+			limits:   DefaultLimits,
+			expected: []string{"main.tex"},
+		},
+		{
+			name:   "Too many entries",
+			files:  map[string]string{"a.tex": "", "b.tex": "", "c.png": ""},
+			limits: Limits{MaxEntries: 2, MaxFileSize: 100, MaxTotalSize: 100},
+			err:    "entries",
+		},
+		{
+			name:   "File too large",
+			files:  map[string]string{"a.tex": strings.Repeat("a", 101)},
+			limits: Limits{MaxEntries: 10, MaxFileSize: 100, MaxTotalSize: 1000},
+			err:    "larger than the limit",
+		},
+		{
+			name:   "Archive too large",
+			files:  map[string]string{"a.tex": strings.Repeat("a", 60), "b.tex": strings.Repeat("b", 60)},
+			limits: Limits{MaxEntries: 10, MaxFileSize: 100, MaxTotalSize: 100},
+			err:    "unpacks to more than the limit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := ReadZip(makeZip(t, tt.files), tt.limits)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("ReadZip() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadZip() error = %v", err)
+			}
+			if len(files) != len(tt.expected) {
+				t.Errorf("ReadZip() = %v, want %v", files, tt.expected)
+			}
+			for _, name := range tt.expected {
+				if _, ok := files[name]; !ok {
+					t.Errorf("ReadZip() is missing %s", name)
+				}
+			}
+		})
+	}
+}
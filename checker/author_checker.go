@@ -0,0 +1,186 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"github.com/dlclark/regexp2"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// authorListConfidence is how confident the reference parser must be in the
+// author list before its formatting is checked. Below this the title was not
+// found, so the list may run into other fields.
+const authorListConfidence = 0.8
+
+// maxAuthors is the most authors JACoW references list before using et al.
+const maxAuthors = 6
+
+// originalText returns the text of a bibitem at a location in the contents.
+func originalText(bibItem structs.BibItem, location structs.Location) (string, bool) {
+	start, end := location.Start-bibItem.Location.Start, location.End-bibItem.Location.Start
+	if start < 0 || start > end || end > len(bibItem.OriginalText) {
+		return "", false
+	}
+	return bibItem.OriginalText[start:end], true
+}
+
+// checksAuthorList reports whether the author list of a bibitem was found well
+// enough to check. Authors of .bib entries are formatted by the bibliography
+// style, so are not checked.
+func checksAuthorList(bibItem structs.BibItem) bool {
+	return !bibItem.IsBibTeX() && bibItem.Parsed.AuthorList.Confidence >= authorListConfidence
+}
+
+// toInitials abbreviates given names, "Jean-Pierre" to "J.-P.".
+func toInitials(names []string) string {
+	var initials []string
+	for _, name := range names {
+		var parts []string
+		for _, part := range strings.Split(name, "-") {
+			if r, _ := utf8.DecodeRuneInString(part); r != utf8.RuneError {
+				parts = append(parts, string(r)+".")
+			}
+		}
+		initials = append(initials, strings.Join(parts, "-"))
+	}
+	return strings.Join(initials, " ")
+}
+
+// organisationWords name a group rather than a person, as in "ATLAS
+// Collaboration" or "Particle Data Group".
+var organisationWords = map[string]bool{
+	"collaboration": true, "collaborations": true, "collab.": true, "team": true, "group": true,
+	"consortium": true, "project": true, "committee": true, "council": true,
+	"organization": true, "organisation": true, "association": true,
+	"society": true, "institute": true, "laboratory": true, "agency": true,
+}
+
+// isOrganisation reports whether a name is that of an organisation, with an
+// organisation word or an acronym such as ATLAS.
+func isOrganisation(words []string) bool {
+	for _, word := range words {
+		if organisationWords[strings.ToLower(word)] {
+			return true
+		}
+		if utf8.RuneCountInString(word) > 1 && strings.ToUpper(word) == word && strings.ToLower(word) != word {
+			return true
+		}
+	}
+	return false
+}
+
+// splitFullName splits a name written with full given names, "Simon van der
+// Meer", into the given names and the surname. It returns false when the name
+// is a single word, or the name of an organisation.
+func splitFullName(name string) ([]string, string, bool) {
+	words := strings.Fields(name)
+	if isOrganisation(words) {
+		return nil, "", false
+	}
+	given := 0
+	for given < len(words)-1 {
+		if r, _ := utf8.DecodeRuneInString(words[given]); !unicode.IsUpper(r) || strings.ContainsAny(words[given], `.\{}`) {
+			break
+		}
+		given++
+	}
+	if given == 0 {
+		return nil, "", false
+	}
+	return words[:given], strings.Join(words[given:], " "), true
+}
+
+// Check that authors are written with their initials before their surname
+// e.g. Smith, J. or John Smith rather than J. Smith. Where the given names end
+// and the surname starts in a full name is a guess, so only names written
+// surname first are fixed.
+func checkAuthorNameOrder(bibItem structs.BibItem) []structs.Issue {
+	if !checksAuthorList(bibItem) {
+		return nil
+	}
+	var issues []structs.Issue
+	for _, author := range bibItem.Parsed.Authors {
+		replacement := ""
+		switch {
+		case author.SurnameFirst:
+			replacement = author.Initials + " " + author.Surname
+		case author.Initials == "":
+			given, surname, ok := splitFullName(author.Surname)
+			if !ok {
+				continue
+			}
+			replacement = toInitials(given) + " " + surname
+		default:
+			continue
+		}
+		issue := structs.Issue{Name: bibItem.Name, Type: "AUTHOR_NAME_ORDER", Location: author.Location, Suggestion: replacement}
+		if _, ok := originalText(bibItem, author.Location); ok && author.SurnameFirst {
+			issue.Fix = &structs.Edit{Location: author.Location, Replacement: replacement}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// Check that the last of two or three authors follows an "and"
+// e.g. J. Smith, S. Person rather than J. Smith and S. Person
+func checkAuthorListAnd(bibItem structs.BibItem) []structs.Issue {
+	authors := bibItem.Parsed.Authors
+	if !checksAuthorList(bibItem) || bibItem.Parsed.EtAl.Found() || len(authors) < 2 || len(authors) > 3 {
+		return nil
+	}
+	location := structs.Location{
+		File:  bibItem.Location.File,
+		Start: authors[len(authors)-2].Location.End,
+		End:   authors[len(authors)-1].Location.Start,
+	}
+	separator, ok := originalText(bibItem, location)
+	if !ok {
+		return nil
+	}
+	for _, word := range strings.FieldsFunc(separator, func(r rune) bool { return unicode.IsSpace(r) || r == ',' || r == '~' }) {
+		if word == "and" || word == `\&` {
+			return nil
+		}
+	}
+	replacement := " and "
+	if len(authors) == 3 {
+		replacement = ", and "
+	}
+	return []structs.Issue{{
+		Name:     bibItem.Name,
+		Type:     "AUTHOR_LIST_AND",
+		Location: location,
+		Fix:      &structs.Edit{Location: location, Replacement: replacement},
+	}}
+}
+
+// Check that more than six authors are shortened to the first author and et al.
+// e.g. A. One, B. Two, C. Three, D. Four, E. Five, F. Six, and G. Seven
+func checkAuthorListTooLong(bibItem structs.BibItem) []structs.Issue {
+	reference := bibItem.Parsed
+	if !checksAuthorList(bibItem) || reference.EtAl.Found() || len(reference.Authors) <= maxAuthors {
+		return nil
+	}
+	issue := structs.Issue{Name: bibItem.Name, Type: "AUTHOR_LIST_TOO_LONG", Location: reference.AuthorList.Location}
+	if first, ok := originalText(bibItem, reference.Authors[0].Location); ok {
+		issue.Fix = &structs.Edit{Location: reference.AuthorList.Location, Replacement: first + ` \emph{et al.}`}
+	}
+	return []structs.Issue{issue}
+}
+
+var commaBeforeEtAl = regexp2.MustCompile(`,(?=\s*(\\(emph|textit)\s*\{\s*|\{\\(em|it)\s+)?et al\.)`, 0)
+
+// Check that a single author is not followed by a comma before et al.
+// e.g. J. Smith, \emph{et al.}
+func detectSingleAuthorCommaBeforeEtAl(bibItem structs.BibItem) (bool, *structs.Location) {
+	if bibItem.IsBibTeX() || len(bibItem.Parsed.Authors) != 1 || !bibItem.Parsed.EtAl.Found() {
+		return false, nil
+	}
+	return detectInRef(commaBeforeEtAl, bibItem)
+}
+
+func fixSingleAuthorCommaBeforeEtAl(_ structs.BibItem, location structs.Location) *structs.Edit {
+	return &structs.Edit{Location: location}
+}
@@ -0,0 +1,115 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"reflect"
+	"testing"
+)
+
+func TestAuthorRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		authors    string
+		expected   []string
+		fixed      string
+		suggestion string
+	}{
+		{
+			name:     "JACoW style",
+			authors:  "J. Smith, S. Person, and A. N. Other",
+			expected: []string{},
+		},
+		{
+			name:     "Surname first",
+			authors:  "Smith, J. R. and S. Person",
+			expected: []string{"AUTHOR_NAME_ORDER"},
+			fixed:    "J. R. Smith and S. Person",
+		},
+		{
+			name:       "Full given names",
+			authors:    "Simon van der Meer",
+			expected:   []string{"AUTHOR_NAME_ORDER"},
+			suggestion: "S. van der Meer",
+		},
+		{
+			name:     "Collaboration",
+			authors:  "ATLAS Collaboration",
+			expected: []string{},
+		},
+		{
+			name:     "Organisation",
+			authors:  "Particle Data Group",
+			expected: []string{},
+		},
+		{
+			name:     "Two authors without and",
+			authors:  "J. Smith, S. Person",
+			expected: []string{"AUTHOR_LIST_AND"},
+			fixed:    "J. Smith and S. Person",
+		},
+		{
+			name:     "Three authors without and",
+			authors:  "J. Smith, S. Person, A. N. Other",
+			expected: []string{"AUTHOR_LIST_AND"},
+			fixed:    "J. Smith, S. Person, and A. N. Other",
+		},
+		{
+			name:     "Four authors without and",
+			authors:  "A. One, B. Two, C. Three, D. Four",
+			expected: []string{},
+		},
+		{
+			name:     "Seven authors",
+			authors:  "A. One, B. Two, C. Three, D. Four, E. Five, F. Six, and G. Seven",
+			expected: []string{"AUTHOR_LIST_TOO_LONG"},
+			fixed:    `A. One \emph{et al.}`,
+		},
+		{
+			name:     "Comma before et al.",
+			authors:  `J. Smith, \emph{et al.}`,
+			expected: []string{"ET_AL_WITH_COMMA"},
+			fixed:    `J. Smith \emph{et al.}`,
+		},
+	}
+
+	registry := NewRegistry()
+	for _, id := range []string{"AUTHOR_NAME_ORDER", "AUTHOR_LIST_AND", "AUTHOR_LIST_TOO_LONG", "ET_AL_WITH_COMMA"} {
+		rule, _ := DefaultRegistry.Rule(id)
+		if err := registry.Register(rule); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n"
+			suffix := `, "A title", \textit{Phys. Rev. Lett.}, vol. 1, p. 2, 2020.` + "\n\\end{thebibliography}\n\\end{document}"
+			contents := prefix + tt.authors + suffix
+			issues := registry.Check(finder.Finder(structs.Request{Content: contents}))
+
+			got := make([]string, 0)
+			for _, issue := range issues {
+				got = append(got, issue.Type)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("Check() = %v, want %v", got, tt.expected)
+			}
+			if len(issues) == 0 {
+				return
+			}
+			edit := issues[0].Fix
+			if tt.fixed == "" {
+				if edit != nil || issues[0].Suggestion != tt.suggestion {
+					t.Errorf("%s has fix %v and suggestion %q, want no fix and %q", issues[0].Type, edit, issues[0].Suggestion, tt.suggestion)
+				}
+				return
+			}
+			if edit == nil {
+				t.Fatalf("%s has no fix", issues[0].Type)
+			}
+			if fixed := contents[:edit.Location.Start] + edit.Replacement + contents[edit.Location.End:]; fixed != prefix+tt.fixed+suffix {
+				t.Errorf("fixed contents = %q, want %q", fixed, prefix+tt.fixed+suffix)
+			}
+		})
+	}
+}
@@ -11,8 +11,9 @@ var noPrefix = regexp2.MustCompile(`\\url\s*{10\.`, 0)
 var containsSpace = regexp2.MustCompile(`doi:\s10`, 0)
 
 var doiIsUrl = regexp2.MustCompile(`https?://(dx\.)?doi.org`, 0)
+var doiUrlPrefix = regexp2.MustCompile(`https?://(dx\.)?doi\.org/`, 0)
 
-var volumeIssue = regexp2.MustCompile(`Vol. \d+, Issue \d+,`, 0)
+var volumeIssue = regexp2.MustCompile(`Vol. (\d+), Issue (\d+),`, 0)
 var apsStyleReference = regexp2.MustCompile(`\d+[, -]+?\d+ \(\d{4}\)`, 0)
 var brokenStyleReference = regexp2.MustCompile(`: N\. p\., \d{4}. Web\.`, 0)
 
@@ -20,19 +21,15 @@ func detectReferenceStyleReference(bibItem structs.BibItem) (bool, *structs.Loca
 	// Check if the reference is in APS style
 	match, err := apsStyleReference.FindStringMatch(bibItem.Ref)
 	if err == nil && match != nil {
-		return true, &structs.Location{
-			Start: match.Index + bibItem.Location.Start,
-			End:   match.Index + match.Length + bibItem.Location.Start,
-		}
+		location := refMatchLocation(bibItem, match.Group)
+		return true, &location
 	}
 
 	// check for broken style that appears in some references ": N. p., \d{4}. Web."
 	match, err = brokenStyleReference.FindStringMatch(bibItem.Ref)
 	if err == nil && match != nil {
-		return true, &structs.Location{
-			Start: match.Index + bibItem.Location.Start,
-			End:   match.Index + match.Length + bibItem.Location.Start,
-		}
+		location := refMatchLocation(bibItem, match.Group)
+		return true, &location
 	}
 
 	return false, nil
@@ -59,7 +56,10 @@ func etAlNotItalic(bibItem structs.BibItem) (bool, *structs.Location) {
 	if err == nil && match != nil {
 		isWrapped := italicEtAl.FindString(bibItem.OriginalText)
 		if isWrapped == "" {
-			location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
+			location := structs.RuneLocation(bibItem.OriginalText, match.Index, match.Length)
+			location.File = bibItem.Location.File
+			location.Start += bibItem.Location.Start
+			location.End += bibItem.Location.Start
 			return true, &location
 		}
 	}
@@ -74,90 +74,109 @@ func detectContainsDoiNotWrappedInUrl(bibItem structs.BibItem) (bool, *structs.L
 	if err == nil && match != nil {
 		isWrapped := wrappedDoi.FindString(bibItem.Ref)
 		if isWrapped == "" {
-			location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
+			location := refMatchLocation(bibItem, match.Group)
 			return true, &location
 		}
 	}
 	return false, nil
 }
 
-// return: issueCode, issueDetected
-// func detectEtAlIssue(ref string) (string, bool) {
-
-// 	findIfEtAlIsPresent := containsEtAl.FindString(ref)
-// 	previousCharacterALetter := false
-// 	for _, letter := range ref {
-
-// 		if letter == '.' {
-// 			authorInitialsFound := true
-// 		}
-
-// 		// prepare for next character
-// 		previousCharacterALetter = false
-// 		if unicode.IsLetter(letter) {
-// 			previousCharacterALetter = true
-// 		}
-// 	}
-// 	return "", false
-// }
+// Check that the doi does not contain a space after the colon
+// e.g. doi: 10.1000/182
+func detectDoiContainsSpace(bibItem structs.BibItem) (bool, *structs.Location) {
+	return detectInRef(containsSpace, bibItem)
+}
 
-func CheckBibItem(bibItem structs.BibItem) []structs.Issue {
-	var issues []structs.Issue
+func fixDoiContainsSpace(_ structs.BibItem, location structs.Location) *structs.Edit {
+	return &structs.Edit{Location: location, Replacement: "doi:10"}
+}
 
-	// et al. should not be proceeded by a comma
-	// eg. L. Kiani et al.,
-	// if found, location := detectSingleAuthorCommaBeforeEtAl(bibItem); found {
-	// 	issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "ET_AL_WITH_COMMA", Location: *location})
-	// }
+// Check that doi has a doi: prefix
+// e.g. \url{10.1000/182}
+func detectNoDoiPrefix(bibItem structs.BibItem) (bool, *structs.Location) {
+	return detectInRef(noPrefix, bibItem)
+}
 
-	// if found, location := detectMultiAuthorNoCommaBeforeEtAl(bibItem); found {
-	// 	issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "ET_AL_WITHOUT_COMMA", Location: *location})
-	// }
+func fixNoDoiPrefix(_ structs.BibItem, location structs.Location) *structs.Edit {
+	// insert the prefix before the "10." that ends the match
+	insertAt := location.End - len("10.")
+	return &structs.Edit{Location: structs.Location{Start: insertAt, End: insertAt}, Replacement: "doi:"}
+}
 
-	if found, location := etAlNotItalic(bibItem); found {
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "ET_AL_NOT_WRAPPED", Location: *location})
+// Check that DOI is not a http link
+// e.g. \url{https://doi.org/10.1000/182}
+// For .bib entries only the doi field is checked, as links in the url field
+// are checked by BIBTEX_DOI_IN_URL.
+func detectDoiIsUrl(bibItem structs.BibItem) (bool, *structs.Location) {
+	if bibItem.IsBibTeX() {
+		return detectInField(doiIsUrl, bibItem, "doi")
 	}
+	return detectInRef(doiIsUrl, bibItem)
+}
 
-	// Check that the doi does not contain a space after the colon
-	// e.g. doi: 10.1000/182
-	match, err := containsSpace.FindStringMatch(bibItem.Ref)
-	if err == nil && match != nil {
-		location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "DOI_CONTAINS_SPACE", Location: location})
+func fixDoiIsUrl(bibItem structs.BibItem, _ structs.Location) *structs.Edit {
+	if bibItem.IsBibTeX() {
+		// the doi field holds the bare DOI, without a doi: prefix
+		if found, location := detectInField(doiUrlPrefix, bibItem, "doi"); found {
+			return &structs.Edit{Location: *location}
+		}
+		return nil
 	}
-
-	// check if reference is using incorrect reference style
-	if found, location := detectReferenceStyleReference(bibItem); found {
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "INCORRECT_STYLE_REFERENCE", Location: *location})
+	match, err := doiUrlPrefix.FindStringMatch(bibItem.Ref)
+	if err != nil || match == nil {
+		return nil
 	}
+	return &structs.Edit{Location: refMatchLocation(bibItem, match.Group), Replacement: "doi:"}
+}
 
-	// Is wrapped in a URL command
-	// e.g. doi:10.1000/182 (without \url{})
-	// if found, location := detectContainsDoiNotWrappedInUrl(bibItem); found {
-	// 	issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "DOI_NOT_WRAPPED", Location: *location})
-	// }
+func detectVolumeIssue(bibItem structs.BibItem) (bool, *structs.Location) {
+	return detectInRef(volumeIssue, bibItem)
+}
 
-	// Check that doi has a doi: prefix
-	// e.g. \url{10.1000/182}
-	match, err = noPrefix.FindStringMatch(bibItem.Ref)
-	if err == nil && match != nil {
-		location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "NO_DOI_PREFIX", Location: location})
+func fixVolumeIssue(bibItem structs.BibItem, location structs.Location) *structs.Edit {
+	match, err := volumeIssue.FindStringMatch(bibItem.Ref)
+	if err != nil || match == nil {
+		return nil
 	}
+	groups := match.Groups()
+	return &structs.Edit{
+		Location:    location,
+		Replacement: "vol. " + groups[1].String() + ", no. " + groups[2].String() + ",",
+	}
+}
 
-	// Check that DOI is not a http link
-	// e.g. \url{https://doi.org/10.1000/182}
-	match, err = doiIsUrl.FindStringMatch(bibItem.Ref)
+func fixEtAlNotItalic(_ structs.BibItem, location structs.Location) *structs.Edit {
+	return &structs.Edit{Location: location, Replacement: "\\emph{et al.}"}
+}
+
+func detectInRef(regex *regexp2.Regexp, bibItem structs.BibItem) (bool, *structs.Location) {
+	match, err := regex.FindStringMatch(bibItem.Ref)
 	if err == nil && match != nil {
-		location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "DOI_IS_URL", Location: location})
+		location := refMatchLocation(bibItem, match.Group)
+		return true, &location
 	}
+	return false, nil
+}
 
-	match, err = volumeIssue.FindStringMatch(bibItem.Ref)
-	if err == nil && match != nil {
-		location := structs.Location{Start: match.Index + bibItem.Location.Start, End: match.Index + match.Length + bibItem.Location.Start}
-		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "VOLUME_ISSUE", Location: location})
+func detectInField(regex *regexp2.Regexp, bibItem structs.BibItem, name string) (bool, *structs.Location) {
+	field, ok := bibItem.Field(name)
+	if !ok {
+		return false, nil
+	}
+	match, err := regex.FindStringMatch(field.Value)
+	if err != nil || match == nil {
+		return false, nil
 	}
+	location := structs.RuneLocation(field.Value, match.Index, match.Length)
+	location.File = field.Location.File
+	location.Start += field.Location.Start
+	location.End += field.Location.Start
+	return true, &location
+}
 
-	return issues
+// refMatchLocation finds where a regexp2 match against the Ref of a bibitem
+// came from in the original contents.
+func refMatchLocation(bibItem structs.BibItem, group regexp2.Group) structs.Location {
+	location := structs.RuneLocation(bibItem.Ref, group.Index, group.Length)
+	return bibItem.RefLocation(location.Start, location.End)
 }
@@ -1,6 +1,7 @@
 package checker
 
 import (
+	"catscan-latex/finder"
 	"catscan-latex/structs"
 	"testing"
 )
@@ -28,7 +29,7 @@ func Test_singleAuthorCommaBeforeEtAl(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "comma before un-italicized et al.",
+			name: "un-italicized et al. without comma",
 			args: args{
 				bibItem: structs.BibItem{
 					Ref: "J. Smith et al.",
@@ -67,10 +68,10 @@ func Test_singleAuthorCommaBeforeEtAl(t *testing.T) {
 			want: true,
 		},
 		{
-			name: "wrapped et al. with comma",
+			name: "wrapped et al. without comma",
 			args: args{
 				bibItem: structs.BibItem{
-					Ref: "J. Smith, \\emph{et al.}",
+					Ref: "J. Smith \\emph{et al.}",
 					Location: structs.Location{
 						Start: 0,
 						End:   0,
@@ -80,7 +81,7 @@ func Test_singleAuthorCommaBeforeEtAl(t *testing.T) {
 			want: false,
 		},
 		{
-			name: "wrapped et al. with comma",
+			name: "two authors before et al.",
 			args: args{
 				bibItem: structs.BibItem{
 					Ref: "J. Smith, S. Person, \\emph{et al.}",
@@ -95,9 +96,11 @@ func Test_singleAuthorCommaBeforeEtAl(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			found, _ := etAlNotItalic(tt.args.bibItem)
+			bibItem := tt.args.bibItem
+			bibItem.Parsed = finder.ParseReference(bibItem)
+			found, _ := detectSingleAuthorCommaBeforeEtAl(bibItem)
 			if found != tt.want {
-				t.Errorf("etAlWithComma() got = %v, want %v", found, tt.want)
+				t.Errorf("detectSingleAuthorCommaBeforeEtAl() got = %v, want %v", found, tt.want)
 			}
 		})
 	}
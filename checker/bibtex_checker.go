@@ -0,0 +1,99 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"fmt"
+	"github.com/dlclark/regexp2"
+	"strings"
+)
+
+// entryTypesExpectingDoi are the .bib entry types which almost always have a
+// DOI, so should have a doi field.
+var entryTypesExpectingDoi = map[string]bool{
+	"article":       true,
+	"inproceedings": true,
+}
+
+func detectBibTeXMissingDoi(bibItem structs.BibItem) (bool, *structs.Location) {
+	if !entryTypesExpectingDoi[bibItem.EntryType] {
+		return false, nil
+	}
+	if _, ok := bibItem.Field("doi"); ok {
+		return false, nil
+	}
+	return true, &bibItem.LabelLocation
+}
+
+var doiInUrl = regexp2.MustCompile(`^\s*((https?://)?(dx\.)?doi\.org/|doi:)?(10\.\d{4,9}/\S+?)\s*$`, regexp2.IgnoreCase)
+
+// Check that a DOI is in the doi field rather than the url field
+// e.g. url = {https://doi.org/10.1000/182}
+func detectBibTeXDoiInUrl(bibItem structs.BibItem) (bool, *structs.Location) {
+	url, ok := bibItem.Field("url")
+	if !ok {
+		return false, nil
+	}
+	if match, err := doiInUrl.FindStringMatch(url.Value); err != nil || match == nil {
+		return false, nil
+	}
+	return true, &url.FieldLocation
+}
+
+func fixBibTeXDoiInUrl(bibItem structs.BibItem, location structs.Location) *structs.Edit {
+	if _, ok := bibItem.Field("doi"); ok {
+		// the url is redundant, but deleting it would leave a dangling comma
+		return nil
+	}
+	url, _ := bibItem.Field("url")
+	match, err := doiInUrl.FindStringMatch(url.Value)
+	if err != nil || match == nil {
+		return nil
+	}
+	return &structs.Edit{Location: location, Replacement: fmt.Sprintf("doi = {%s}", match.Groups()[4].String())}
+}
+
+var proceedingsName = regexp2.MustCompile(`\b(Proc\.|Proceedings|Conf\.|Conference|Workshop|Symposium)\b|\b(IPAC|LINAC|NAPAC|IBIC|ICALEPCS|FEL|SRF|PAC|EPAC|CYCLOTRONS|HIAT)'?\d{2}`, 0)
+
+// Check that conference proceedings use @inproceedings
+// e.g. @article{a, journal = {Proc. IPAC'23}}
+func detectBibTeXWrongEntryType(bibItem structs.BibItem) (bool, *structs.Location) {
+	if !bibItem.IsBibTeX() || bibItem.EntryType == "inproceedings" || bibItem.EntryType == "proceedings" || bibItem.EntryType == "conference" {
+		return false, nil
+	}
+	if strings.Contains(strings.ToLower(bibItem.Doi), "10.18429/jacow-") {
+		return true, &bibItem.EntryTypeLocation
+	}
+	for _, name := range []string{"journal", "booktitle", "series"} {
+		field, ok := bibItem.Field(name)
+		if !ok {
+			continue
+		}
+		// journals such as J. Phys.: Conf. Ser. publish proceedings as articles
+		if isJournal(field.Value) {
+			continue
+		}
+		if match, err := proceedingsName.FindStringMatch(field.Value); err == nil && match != nil {
+			return true, &bibItem.EntryTypeLocation
+		}
+	}
+	return false, nil
+}
+
+// fixBibTeXWrongEntryType changes the entry type to @inproceedings, and renames
+// its journal field to booktitle in the same edit, as @inproceedings entries
+// name their proceedings in booktitle.
+func fixBibTeXWrongEntryType(bibItem structs.BibItem, location structs.Location) *structs.Edit {
+	journal, ok := bibItem.Field("journal")
+	if _, hasBooktitle := bibItem.Field("booktitle"); !ok || hasBooktitle {
+		return &structs.Edit{Location: location, Replacement: "inproceedings"}
+	}
+	nameEnd := journal.FieldLocation.Start + len("journal")
+	between, ok := originalText(bibItem, structs.Location{Start: location.End, End: journal.FieldLocation.Start})
+	if !ok {
+		return nil
+	}
+	return &structs.Edit{
+		Location:    structs.Location{Start: location.Start, End: nameEnd},
+		Replacement: "inproceedings" + between + "booktitle",
+	}
+}
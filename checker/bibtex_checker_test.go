@@ -0,0 +1,52 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"testing"
+)
+
+func TestBibTeXRules(t *testing.T) {
+	tests := []struct {
+		name      string
+		entry     string
+		issueType string
+		found     bool
+		fixed     string
+	}{
+		{"Article without doi", "@article{a, title = {T}}", "BIBTEX_MISSING_DOI", true, ""},
+		{"Article with doi", "@article{a, doi = {10.1000/182}}", "BIBTEX_MISSING_DOI", false, ""},
+		{"Book without doi", "@book{a, title = {T}}", "BIBTEX_MISSING_DOI", false, ""},
+		{"DOI in url", "@misc{a, url = {https://doi.org/10.1000/182}}", "BIBTEX_DOI_IN_URL", true, "@misc{a, doi = {10.1000/182}}"},
+		{"DOI in url and doi", "@misc{a, url = {https://doi.org/10.1000/182}, doi = {10.1000/182}}", "BIBTEX_DOI_IN_URL", true, ""},
+		{"Web page in url", "@misc{a, url = {https://www.jacow.org}}", "BIBTEX_DOI_IN_URL", false, ""},
+		{"Proceedings as article", "@article{a, journal = {Proc. IPAC'23}}", "BIBTEX_WRONG_ENTRY_TYPE", true, "@inproceedings{a, booktitle = {Proc. IPAC'23}}"},
+		{"Proceedings as article with other fields", "@article{a, author = {A. Smith}, journal = {Proc. IPAC'23}, year = {2023}}", "BIBTEX_WRONG_ENTRY_TYPE", true, "@inproceedings{a, author = {A. Smith}, booktitle = {Proc. IPAC'23}, year = {2023}}"},
+		{"Proceedings journal", "@article{a, journal = {J. Phys.: Conf. Ser.}}", "BIBTEX_WRONG_ENTRY_TYPE", false, ""},
+		{"JACoW DOI as misc", "@misc{a, doi = {10.18429/JACoW-IPAC2023-MOPA001}}", "BIBTEX_WRONG_ENTRY_TYPE", true, "@inproceedings{a, doi = {10.18429/JACoW-IPAC2023-MOPA001}}"},
+		{"Journal article", "@article{a, journal = {Phys. Rev. Lett.}}", "BIBTEX_WRONG_ENTRY_TYPE", false, ""},
+		{"Inproceedings", "@inproceedings{a, booktitle = {Proc. IPAC'23}}", "BIBTEX_WRONG_ENTRY_TYPE", false, ""},
+		{"DOI field is a URL", "@article{a, doi = {https://doi.org/10.1000/182}}", "DOI_IS_URL", true, "@article{a, doi = {10.1000/182}}"},
+		{"Bare DOI field", "@article{a, doi = {10.1000/182}}", "DOI_IS_URL", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := finder.ParseBibTeX("refs.bib", tt.entry)[0]
+			rule, ok := DefaultRegistry.Rule(tt.issueType)
+			if !ok {
+				t.Fatalf("no rule %s", tt.issueType)
+			}
+			issues := rule.Check(Target{BibItem: item})
+			if (len(issues) > 0) != tt.found {
+				t.Fatalf("%s found %v, want %v", tt.issueType, issues, tt.found)
+			}
+			fixed := ""
+			if len(issues) > 0 && issues[0].Fix != nil {
+				edit := issues[0].Fix
+				fixed = tt.entry[:edit.Location.Start] + edit.Replacement + tt.entry[edit.Location.End:]
+			}
+			if fixed != tt.fixed {
+				t.Errorf("fixed entry = %q, want %q", fixed, tt.fixed)
+			}
+		})
+	}
+}
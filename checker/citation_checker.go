@@ -0,0 +1,200 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// isCited reports whether a bibitem is cited, or every bibitem is cited with
+// \nocite{*}.
+func isCited(bibItem structs.BibItem, citations []structs.Citation) bool {
+	for _, citation := range citations {
+		if citation.Name == bibItem.Name || citation.Name == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+var bibItemNotCitedRule = NewRule("BIBITEM_NOT_CITED", "Reference is never cited in the text", structs.SeverityWarning, ScopeBibItem,
+	func(target Target) []structs.Issue {
+		if target.BibItem.IsBibTeX() || isCited(target.BibItem, target.Contents.Citations) {
+			// only cited .bib entries are read, so they are always cited
+			return nil
+		}
+		return []structs.Issue{{Name: target.BibItem.Name, Type: "BIBITEM_NOT_CITED", Location: target.BibItem.LabelLocation}}
+	})
+
+// Without any bibitems the bibliography was not sent, so there is nothing to
+// check citations against.
+var citationNotFoundRule = NewRule("CITATION_NOT_FOUND", "Citation has no matching reference", structs.SeverityError, ScopeCitation,
+	func(target Target) []structs.Issue {
+		if target.Citation.Name == "*" || len(target.Contents.BibItems) == 0 {
+			return nil
+		}
+		for _, bibItem := range target.Contents.BibItems {
+			if bibItem.Name == target.Citation.Name {
+				return nil
+			}
+		}
+		return []structs.Issue{{Name: target.Citation.Name, Type: "CITATION_NOT_FOUND", Location: target.Citation.Location}}
+	})
+
+// citationOrder returns the bibitems of thebibliography in the order they are
+// first cited. Those never cited keep their order after the cited ones.
+func citationOrder(bibItems []structs.BibItem, citations []structs.Citation) []int {
+	index := make(map[string]int)
+	for i, bibItem := range bibItems {
+		if _, ok := index[bibItem.Name]; !ok {
+			index[bibItem.Name] = i
+		}
+	}
+	order := make([]int, 0, len(bibItems))
+	placed := make(map[int]bool)
+	for _, citation := range citations {
+		if i, ok := index[citation.Name]; ok && !placed[i] {
+			order = append(order, i)
+			placed[i] = true
+		}
+	}
+	for i := range bibItems {
+		if !placed[i] {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// reorderBibliography suggests the bibitems in the given order, keeping the
+// whitespace between them where it is. There is no edit when the bibitems do
+// not follow one another, such as when split over two bibliographies.
+func reorderBibliography(contents string, bibItems []structs.BibItem, order []int) *structs.Edit {
+	texts := make([]string, len(bibItems))
+	separators := make([]string, len(bibItems))
+	for i, bibItem := range bibItems {
+		if i > 0 && bibItems[i-1].Location.End != bibItem.LabelLocation.Start {
+			return nil
+		}
+		text := contents[bibItem.LabelLocation.Start:bibItem.Location.End]
+		texts[i] = strings.TrimRight(text, " \t\r\n")
+		separators[i] = text[len(texts[i]):]
+	}
+	var replacement strings.Builder
+	for position, i := range order {
+		replacement.WriteString(texts[i])
+		replacement.WriteString(separators[position])
+	}
+	return &structs.Edit{
+		Location: structs.Location{
+			Start: bibItems[0].LabelLocation.Start,
+			End:   bibItems[len(bibItems)-1].Location.End,
+		},
+		Replacement: replacement.String(),
+	}
+}
+
+// inIncreasingOrder marks the values of the longest increasing subsequence of
+// values, those that can stay where they are while the rest are moved.
+func inIncreasingOrder(values []int) []bool {
+	// tails[n] is the index of the smallest value ending an increasing
+	// subsequence of length n+1, and previous links each value to the one
+	// before it in its subsequence.
+	var tails []int
+	previous := make([]int, len(values))
+	for i, value := range values {
+		n := sort.Search(len(tails), func(n int) bool { return values[tails[n]] >= value })
+		previous[i] = -1
+		if n > 0 {
+			previous[i] = tails[n-1]
+		}
+		if n == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[n] = i
+		}
+	}
+	kept := make([]bool, len(values))
+	if len(tails) > 0 {
+		for i := tails[len(tails)-1]; i >= 0; i = previous[i] {
+			kept[i] = true
+		}
+	}
+	return kept
+}
+
+// outOfCitationOrder finds the order the bibitems of thebibliography should be
+// in, and the fewest cited bibitems that must move to put them in it, with the
+// position each should have. Bibitems never cited are not moved, as they are
+// left to BIBITEM_NOT_CITED, and entries from .bib files are ordered by BibTeX.
+func outOfCitationOrder(result structs.Contents) (bibItems []structs.BibItem, order []int, moved map[int]int) {
+	for _, bibItem := range result.BibItems {
+		if !bibItem.IsBibTeX() {
+			bibItems = append(bibItems, bibItem)
+		}
+	}
+	order = citationOrder(bibItems, result.Citations)
+	positions := make(map[int]int)
+	for position, i := range order {
+		if isCited(bibItems[i], result.Citations) {
+			positions[i] = position
+		}
+	}
+	var cited, citedPositions []int
+	for i := range bibItems {
+		if position, ok := positions[i]; ok {
+			cited = append(cited, i)
+			citedPositions = append(citedPositions, position)
+		}
+	}
+	moved = make(map[int]int)
+	for j, kept := range inIncreasingOrder(citedPositions) {
+		if !kept {
+			moved[cited[j]] = positions[cited[j]]
+		}
+	}
+	return bibItems, order, moved
+}
+
+// JACoW numbers references in the order they are first cited. The fewest
+// cited bibitems that must move to put the bibliography in order are reported
+// with the position they should have. The issues have no fix, as reordering
+// replaces the whole bibliography, which would overlap every other fix in it.
+// CitationOrderFix reorders it once those are applied.
+var citationOrderRule = NewRule("CITATION_ORDER", "References are not in the order they are first cited", structs.SeverityWarning, ScopeDocument,
+	func(target Target) []structs.Issue {
+		bibItems, _, moved := outOfCitationOrder(target.Contents)
+		var issues []structs.Issue
+		for i, bibItem := range bibItems {
+			if position, ok := moved[i]; ok {
+				issues = append(issues, structs.Issue{
+					Name:       bibItem.Name,
+					Type:       "CITATION_ORDER",
+					Location:   bibItem.LabelLocation,
+					Suggestion: fmt.Sprintf("[%d]", position+1),
+				})
+			}
+		}
+		return issues
+	})
+
+// CitationOrderFix is the edit putting the bibliography in the order the
+// references are first cited, against the file the bibliography is in. It is
+// nil when CITATION_ORDER reports nothing, or the bibliography cannot be
+// reordered in one edit, such as when split over two files.
+func CitationOrderFix(result structs.Contents) *structs.Edit {
+	bibItems, order, moved := outOfCitationOrder(result)
+	if len(moved) == 0 {
+		return nil
+	}
+	edit := reorderBibliography(result.Content, bibItems, order)
+	if edit == nil {
+		return nil
+	}
+	location := result.Resolve(edit.Location)
+	if location.End-location.Start != edit.Location.End-edit.Location.Start {
+		return nil
+	}
+	return &structs.Edit{Location: location, Replacement: edit.Replacement}
+}
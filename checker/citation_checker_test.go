@@ -0,0 +1,119 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCitationRules(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+	}{
+		{
+			name:     "Every reference cited",
+			body:     `\cite{a,b}`,
+			expected: []string{},
+		},
+		{
+			name:     "Reference not cited",
+			body:     `\cite{a}`,
+			expected: []string{"BIBITEM_NOT_CITED b"},
+		},
+		{
+			name:     "Citation without a reference",
+			body:     `\cite{a,b,c}`,
+			expected: []string{"CITATION_NOT_FOUND c"},
+		},
+		{
+			name:     "Every reference cited with nocite",
+			body:     `\nocite{*}`,
+			expected: []string{},
+		},
+	}
+
+	registry := NewRegistry(bibItemNotCitedRule, citationNotFoundRule)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n" + tt.body + "\n\\begin{thebibliography}{9}\n\\bibitem{a} A.\n\\bibitem{b} B.\n\\end{thebibliography}\n\\end{document}"
+			got := make([]string, 0)
+			for _, issue := range registry.Check(finder.Finder(structs.Request{Content: contents})) {
+				got = append(got, issue.Type+" "+issue.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Check() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCitationOrderRule(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected []string
+		fixed    string
+	}{
+		{
+			name:     "In order",
+			body:     `\cite{a} \cite{b,c}`,
+			expected: []string{},
+		},
+		{
+			name:     "One reference out of order",
+			body:     `\cite{c} \cite{a} \cite{c,b}`,
+			expected: []string{"c [1]"},
+			fixed:    "\\bibitem{c} C.\n\\bibitem{a} A.\n\\bibitem{b} B.\n",
+		},
+		{
+			name:     "Two references out of order",
+			body:     `\cite{c} \cite{b} \cite{a}`,
+			expected: []string{"a [3]", "b [2]"},
+			fixed:    "\\bibitem{c} C.\n\\bibitem{b} B.\n\\bibitem{a} A.\n",
+		},
+		{
+			name:     "Uncited reference not reported",
+			body:     `\cite{c} \cite{b}`,
+			expected: []string{"b [2]"},
+			fixed:    "\\bibitem{c} C.\n\\bibitem{b} B.\n\\bibitem{a} A.\n",
+		},
+		{
+			name:     "Only uncited references out of place",
+			body:     `\cite{b}`,
+			expected: []string{},
+		},
+	}
+
+	registry := NewRegistry(citationOrderRule)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bibliography := "\\bibitem{a} A.\n\\bibitem{b} B.\n\\bibitem{c} C.\n"
+			contents := "\\begin{document}\n" + tt.body + "\n\\begin{thebibliography}{9}\n" + bibliography + "\\end{thebibliography}\n\\end{document}"
+			result := finder.Finder(structs.Request{Content: contents})
+			got := make([]string, 0)
+			for _, issue := range registry.Check(result) {
+				if issue.Fix != nil {
+					t.Errorf("issue %s has a fix, want the reorder left to CitationOrderFix", issue.Name)
+				}
+				got = append(got, issue.Name+" "+issue.Suggestion)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("Check() = %v, want %v", got, tt.expected)
+			}
+			edit := CitationOrderFix(result)
+			if len(got) == 0 {
+				if edit != nil {
+					t.Errorf("CitationOrderFix() = %v, want nil", edit)
+				}
+				return
+			}
+			if got := contents[:edit.Location.Start] + edit.Replacement + contents[edit.Location.End:]; got != strings.Replace(contents, bibliography, tt.fixed, 1) {
+				t.Errorf("fixed contents = %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultPositiveTTL and DefaultNegativeTTL are how long a DOI that resolved,
+// or did not, is remembered. DOIs rarely stop resolving, but one that does not
+// resolve yet may be registered soon after a conference.
+const (
+	DefaultPositiveTTL = 30 * 24 * time.Hour
+	DefaultNegativeTTL = 24 * time.Hour
+)
+
+// DefaultFlushInterval is how often FlushEvery writes new lookups to the file.
+const DefaultFlushInterval = time.Minute
+
+// DOICacheEntry is the result of resolving a DOI, and when it was found.
+type DOICacheEntry struct {
+	DOI      string    `json:"doi"`
+	Resolves bool      `json:"resolves"`
+	Checked  time.Time `json:"checked"`
+	Expires  time.Time `json:"expires"`
+}
+
+// CachedResolver remembers the results of another resolver in a JSON file, so
+// they survive restarts. Errors are not remembered. New lookups are only kept
+// in memory until Flush, FlushEvery or Close writes them, so lookups are not
+// held up writing the file.
+type CachedResolver struct {
+	resolver    DOIResolver
+	fileName    string
+	positiveTTL time.Duration
+	negativeTTL time.Duration
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]DOICacheEntry
+	dirty   bool
+
+	// saveMu is held while the file is written, so writes do not interleave.
+	saveMu sync.Mutex
+}
+
+// NewCachedResolver creates a cache of the resolver, stored in fileName, which
+// is read if it exists.
+func NewCachedResolver(resolver DOIResolver, fileName string, positiveTTL time.Duration, negativeTTL time.Duration) (*CachedResolver, error) {
+	cache := &CachedResolver{
+		resolver:    resolver,
+		fileName:    fileName,
+		positiveTTL: positiveTTL,
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		entries:     make(map[string]DOICacheEntry),
+	}
+	content, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var loaded []DOICacheEntry
+	if err := json.Unmarshal(content, &loaded); err != nil {
+		return nil, fmt.Errorf("failed to parse DOI cache %s: %w", fileName, err)
+	}
+	for _, entry := range loaded {
+		cache.entries[finder.DOIKey(entry.DOI)] = entry
+	}
+	return cache, nil
+}
+
+func (c *CachedResolver) Resolve(ctx context.Context, doi string) (bool, error) {
+	key := finder.DOIKey(doi)
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.Expires) {
+		return entry.Resolves, nil
+	}
+
+	resolves, err := c.resolver.Resolve(ctx, doi)
+	if err != nil {
+		return false, err
+	}
+	ttl := c.negativeTTL
+	if resolves {
+		ttl = c.positiveTTL
+	}
+	now := c.now()
+	c.mu.Lock()
+	c.entries[key] = DOICacheEntry{DOI: doi, Resolves: resolves, Checked: now, Expires: now.Add(ttl)}
+	c.dirty = true
+	c.mu.Unlock()
+	return resolves, nil
+}
+
+// Entries lists the remembered results by DOI. Expired results are listed until
+// the cache is next saved.
+func (c *CachedResolver) Entries() []DOICacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries := make([]DOICacheEntry, 0, len(c.entries))
+	for _, entry := range c.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return finder.DOIKey(entries[i].DOI) < finder.DOIKey(entries[j].DOI) })
+	return entries
+}
+
+// Purge forgets the result for a DOI, or every result when doi is empty, so
+// it is resolved again. It returns the number of entries removed.
+func (c *CachedResolver) Purge(doi string) (int, error) {
+	c.mu.Lock()
+	removed := len(c.entries)
+	if doi == "" {
+		c.entries = make(map[string]DOICacheEntry)
+	} else {
+		delete(c.entries, finder.DOIKey(doi))
+	}
+	removed -= len(c.entries)
+	c.dirty = true
+	c.mu.Unlock()
+	return removed, c.Flush()
+}
+
+// Flush writes the unexpired entries to the file if there have been lookups
+// since it was last written. The entries are copied, so lookups carry on while
+// the file is written.
+func (c *CachedResolver) Flush() error {
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+	c.mu.Lock()
+	if !c.dirty {
+		c.mu.Unlock()
+		return nil
+	}
+	now := c.now()
+	entries := make([]DOICacheEntry, 0, len(c.entries))
+	for key, entry := range c.entries {
+		if !now.Before(entry.Expires) {
+			delete(c.entries, key)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	if err := c.save(entries); err != nil {
+		c.mu.Lock()
+		c.dirty = true
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// FlushEvery calls Flush every interval until ctx is done, and once more then.
+func (c *CachedResolver) FlushEvery(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			if err := c.Flush(); err != nil {
+				log.Printf("Error saving DOI cache: %v", err)
+			}
+			return
+		}
+		if err := c.Flush(); err != nil {
+			log.Printf("Error saving DOI cache: %v", err)
+		}
+	}
+}
+
+// Close writes any lookups not yet written to the file.
+func (c *CachedResolver) Close() error {
+	return c.Flush()
+}
+
+// save writes entries to the file, replacing it in one step so a crash cannot
+// leave it half written. The caller must hold c.saveMu.
+func (c *CachedResolver) save(entries []DOICacheEntry) error {
+	sort.Slice(entries, func(i, j int) bool { return finder.DOIKey(entries[i].DOI) < finder.DOIKey(entries[j].DOI) })
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(c.fileName), filepath.Base(c.fileName)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to save DOI cache: %w", err)
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to save DOI cache: %w", err)
+	}
+	if err := temp.Close(); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to save DOI cache: %w", err)
+	}
+	if err := os.Rename(temp.Name(), c.fileName); err != nil {
+		os.Remove(temp.Name())
+		return fmt.Errorf("failed to save DOI cache: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// countingResolver counts the lookups that reach the resolver it wraps.
+type countingResolver struct {
+	resolver *MemoryResolver
+	lookups  int
+}
+
+func (r *countingResolver) Resolve(ctx context.Context, doi string) (bool, error) {
+	r.lookups++
+	return r.resolver.Resolve(ctx, doi)
+}
+
+func TestCachedResolver(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "doi_cache.json")
+	counting := &countingResolver{resolver: NewMemoryResolver("10.1103/found")}
+	cache, err := NewCachedResolver(counting, fileName, time.Hour, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedResolver() error = %v", err)
+	}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	cache.now = func() time.Time { return now }
+
+	resolve := func(doi string, want bool, wantLookups int) {
+		t.Helper()
+		got, err := cache.Resolve(context.Background(), doi)
+		if err != nil {
+			t.Fatalf("Resolve(%s) error = %v", doi, err)
+		}
+		if got != want || counting.lookups != wantLookups {
+			t.Errorf("Resolve(%s) = %v after %d lookups, want %v after %d", doi, got, counting.lookups, want, wantLookups)
+		}
+	}
+
+	resolve("10.1103/found", true, 1)
+	resolve("10.1103/FOUND", true, 1)
+	resolve("10.1103/missing", false, 2)
+	resolve("10.1103/missing", false, 2)
+	if _, err := os.Stat(fileName); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("cache file written before Flush, Stat() error = %v", err)
+	}
+
+	// the negative result expires first
+	now = now.Add(2 * time.Minute)
+	resolve("10.1103/found", true, 2)
+	resolve("10.1103/missing", false, 3)
+
+	// results survive a restart once written
+	if err := cache.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	reloaded, err := NewCachedResolver(counting, fileName, time.Hour, time.Minute)
+	if err != nil {
+		t.Fatalf("NewCachedResolver() error = %v", err)
+	}
+	reloaded.now = cache.now
+	if entries := reloaded.Entries(); len(entries) != 2 || entries[0].DOI != "10.1103/found" || !entries[0].Resolves || entries[1].Resolves {
+		t.Errorf("Entries() = %v, want the two remembered lookups", entries)
+	}
+	cache = reloaded
+	resolve("10.1103/found", true, 3)
+
+	removed, err := cache.Purge("10.1103/FOUND")
+	if err != nil || removed != 1 {
+		t.Errorf("Purge() = %d, %v, want 1", removed, err)
+	}
+	resolve("10.1103/found", true, 4)
+	if removed, err := cache.Purge(""); err != nil || removed != 2 {
+		t.Errorf("Purge() of every entry = %d, %v, want 2", removed, err)
+	}
+}
+
+func TestCachedResolver_ErrorsNotRemembered(t *testing.T) {
+	failing := &MemoryResolver{Err: errors.New("unreachable")}
+	cache, err := NewCachedResolver(failing, filepath.Join(t.TempDir(), "doi_cache.json"), time.Hour, time.Hour)
+	if err != nil {
+		t.Fatalf("NewCachedResolver() error = %v", err)
+	}
+	if _, err := cache.Resolve(context.Background(), "10.1103/found"); err == nil {
+		t.Fatalf("Resolve() expected the resolver's error")
+	}
+	if entries := cache.Entries(); len(entries) != 0 {
+		t.Errorf("Entries() = %v, want the error not to be remembered", entries)
+	}
+}
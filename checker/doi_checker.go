@@ -1,102 +1,103 @@
 package checker
 
 import (
+	"catscan-latex/finder"
 	"catscan-latex/structs"
-	"fmt"
+	"context"
 	"log"
-	"net/http"
 	"strings"
-	"time"
 )
 
-func checkDOIExists(doi string) (bool, error) {
-	doiLookupURL := "https://doi.org/" + doi
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-		CheckRedirect: func(req *http.Request, via []*http.Request) error {
-			// Do not follow redirects
-			return http.ErrUseLastResponse
-		},
+// trimDOIEdit removes the punctuation written after the DOI.
+func trimDOIEdit(bibItem structs.BibItem, trailing string) *structs.Edit {
+	if bibItem.DoiLocation.End == 0 {
+		return nil
 	}
-
-	resp, err := client.Head(doiLookupURL)
-
-	if err != nil {
-		return false, fmt.Errorf("failed to check DOI: %w", err)
+	start := bibItem.NormalisedDoiLocation.End
+	return &structs.Edit{
+		Location: structs.Location{File: bibItem.DoiLocation.File, Start: start, End: start + len(trailing)},
 	}
-	defer resp.Body.Close()
+}
 
-	// Consider DOI valid if status is 200 OK or a redirect (3xx)
-	if resp.StatusCode >= 200 && resp.StatusCode < 400 {
-		return true, nil
+// doiTrailing is the punctuation written after the DOI of a bibitem that
+// belongs to the DOI, rather than to the reference. A closing parenthesis
+// matching one opened before the DOI, as in "(DOI: 10.1103/PhysRevLett.1.2)",
+// closes the reference's own parentheses, so it and what follows are not the
+// DOI's.
+func doiTrailing(bibItem structs.BibItem, trailing string) string {
+	closing := strings.IndexByte(trailing, ')')
+	if closing == -1 {
+		return trailing
 	}
-
-	return false, nil
+	before, ok := originalText(bibItem, structs.Location{Start: bibItem.Location.Start, End: bibItem.DoiLocation.Start})
+	if !ok || strings.Count(before, "(") <= strings.Count(before, ")") {
+		return trailing
+	}
+	return trailing[:closing]
 }
 
-func tryTrimDOI(originalDOI string, cutset string) (string, error) {
-	trimmedDOI := strings.TrimRight(originalDOI, cutset)
-	if trimmedDOI != originalDOI {
-		exists, err := checkDOIExists(trimmedDOI)
-		if err != nil {
-			return "", fmt.Errorf("error checking DOI with no %s: %w", cutset, err)
-		}
-		if exists {
-			return trimmedDOI, nil
-		}
+// trailingPunctuationType is the issue type for punctuation written after a
+// DOI.
+func trailingPunctuationType(trailing string) string {
+	switch {
+	case strings.Contains(trailing, ")"):
+		return "DOI_ENDS_IN_PARENTHESIS"
+	case strings.Contains(trailing, "."):
+		return "DOI_ENDS_IN_PERIOD"
 	}
-	return "", nil
+	return "DOI_ENDS_IN_PUNCTUATION"
 }
 
-func CheckDOIExists(bibItem structs.BibItem) *structs.Issue {
-	currentDOI := bibItem.Doi
-	if currentDOI == "" {
-		return nil
-	}
-	doiExists, err := checkDOIExists(currentDOI)
-	if err != nil {
-		log.Printf("Error checking exists DOI %s: %v", bibItem.Doi, err)
+// CheckDOIExists looks up the normalised DOI of a bibitem with the resolver.
+// When it resolves, but was written with trailing punctuation, the punctuation
+// is reported, unless the DOI including it resolves too, or it closes
+// parentheses opened before the DOI. A DOI that could not
+// be looked up is reported as unverified, unless the check was cancelled.
+func CheckDOIExists(ctx context.Context, resolver DOIResolver, bibItem structs.BibItem) *structs.Issue {
+	doi := bibItem.NormalisedDoi
+	if doi == "" || resolver == nil {
 		return nil
 	}
-	if !doiExists {
-		newDOI, err := tryTrimDOI(currentDOI, ".")
-		if err != nil {
-			log.Printf("Error checked trimmed DOI %s: %v", currentDOI, err)
+	_, length := finder.NormaliseDOI(bibItem.Doi)
+	trailing := doiTrailing(bibItem, bibItem.Doi[length:])
+	if trailing != "" {
+		// a few DOIs do end in punctuation
+		if written, err := resolver.Resolve(ctx, finder.UnescapeDOI(bibItem.Doi[:length]+trailing)); err == nil && written {
 			return nil
 		}
-		if newDOI != "" {
-			return &structs.Issue{
-				Name:       bibItem.Name,
-				Location:   bibItem.Location,
-				Type:       "DOI_ENDS_IN_PERIOD",
-				Suggestion: newDOI,
-			}
-		}
 	}
-	if !doiExists {
-		newDOI, err := tryTrimDOI(currentDOI, ")")
-		if err != nil {
-			log.Printf("Error checked trimmed DOI %s: %v", currentDOI, err)
+
+	doiExists, err := resolver.Resolve(ctx, doi)
+	if err != nil {
+		if ctx.Err() != nil {
 			return nil
 		}
-		if newDOI != "" {
-			return &structs.Issue{
-				Name:       bibItem.Name,
-				Location:   bibItem.Location,
-				Type:       "DOI_ENDS_IN_PARENTHESIS",
-				Suggestion: newDOI,
-			}
+		log.Printf("Error checking exists DOI %s: %v", doi, err)
+		return &structs.Issue{
+			Name:     bibItem.Name,
+			Location: bibItem.DoiLocation,
+			Type:     "DOI_UNVERIFIED",
+			Severity: structs.SeverityInfo,
 		}
 	}
-
 	if !doiExists {
+		// Name the DOI that was most likely meant, when it can be worked out offline
+		_, likely := ValidateJacowDOI(doi)
 		return &structs.Issue{
-			Name:     bibItem.Name,
-			Location: bibItem.Location,
-			Type:     "DOI_NOT_FOUND",
+			Name:       bibItem.Name,
+			Location:   bibItem.DoiLocation,
+			Type:       "DOI_NOT_FOUND",
+			Suggestion: likely,
+		}
+	}
+	if trailing != "" {
+		return &structs.Issue{
+			Name:       bibItem.Name,
+			Location:   bibItem.Location,
+			Type:       trailingPunctuationType(trailing),
+			Suggestion: doi,
+			Fix:        trimDOIEdit(bibItem, trailing),
 		}
 	}
-
 	return nil
 }
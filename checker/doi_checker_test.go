@@ -0,0 +1,272 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCheckDOIExists(t *testing.T) {
+	tests := []struct {
+		name       string
+		doi        string
+		resolver   *MemoryResolver
+		issueType  string
+		suggestion string
+		fixed      string
+	}{
+		{
+			name:     "Resolves",
+			doi:      "10.1103/PhysRevLett.1.1",
+			resolver: NewMemoryResolver("10.1103/PhysRevLett.1.1"),
+		},
+		{
+			name:       "Ends in period",
+			doi:        "10.1103/PhysRevLett.1.1.",
+			resolver:   NewMemoryResolver("10.1103/PhysRevLett.1.1"),
+			issueType:  "DOI_ENDS_IN_PERIOD",
+			suggestion: "10.1103/PhysRevLett.1.1",
+			fixed:      `\url{doi:10.1103/PhysRevLett.1.1}`,
+		},
+		{
+			name:       "Ends in parenthesis",
+			doi:        "10.1103/PhysRevLett.1.1)",
+			resolver:   NewMemoryResolver("10.1103/PhysRevLett.1.1"),
+			issueType:  "DOI_ENDS_IN_PARENTHESIS",
+			suggestion: "10.1103/PhysRevLett.1.1",
+			fixed:      `\url{doi:10.1103/PhysRevLett.1.1}`,
+		},
+		{
+			name:     "Ends in a period that is part of the DOI",
+			doi:      "10.1103/PhysRevLett.1.1.",
+			resolver: NewMemoryResolver("10.1103/PhysRevLett.1.1."),
+		},
+		{
+			name:       "Ends in a semicolon",
+			doi:        "10.1103/PhysRevLett.1.1;",
+			resolver:   NewMemoryResolver("10.1103/PhysRevLett.1.1"),
+			issueType:  "DOI_ENDS_IN_PUNCTUATION",
+			suggestion: "10.1103/PhysRevLett.1.1",
+			fixed:      `\url{doi:10.1103/PhysRevLett.1.1}`,
+		},
+		{
+			name:     "Escaped underscore",
+			doi:      `10.1002/a\_b`,
+			resolver: NewMemoryResolver("10.1002/a_b"),
+		},
+		{
+			name:      "Not found",
+			doi:       "10.1103/PhysRevLett.1.1",
+			resolver:  NewMemoryResolver(),
+			issueType: "DOI_NOT_FOUND",
+		},
+		{
+			name:       "Not found names the likely JACoW DOI",
+			doi:        "10.18429/JACoW-IPCA2023-MOPA012",
+			resolver:   NewMemoryResolver(),
+			issueType:  "DOI_NOT_FOUND",
+			suggestion: "10.18429/JACoW-IPAC2023-MOPA012",
+		},
+		{
+			name:      "Resolver fails",
+			doi:       "10.1103/PhysRevLett.1.1",
+			resolver:  &MemoryResolver{Err: errors.New("unreachable")},
+			issueType: "DOI_UNVERIFIED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nA. Author, \\emph{Phys. Rev. Lett.}, vol. 1, p. 1, 2020. \\url{doi:" + tt.doi + "}\n\\end{thebibliography}\n\\end{document}"
+			issue := CheckDOIExists(context.Background(), tt.resolver, finder.Finder(structs.Request{Content: contents}).BibItems[0])
+			if tt.issueType == "" {
+				if issue != nil {
+					t.Fatalf("CheckDOIExists() = %v, want nil", issue)
+				}
+				return
+			}
+			if issue == nil || issue.Type != tt.issueType || issue.Suggestion != tt.suggestion {
+				t.Fatalf("CheckDOIExists() = %v, want %s with suggestion %q", issue, tt.issueType, tt.suggestion)
+			}
+			if issue.Type == "DOI_NOT_FOUND" || issue.Type == "DOI_UNVERIFIED" {
+				if located := contents[issue.Location.Start:issue.Location.End]; located != tt.doi {
+					t.Errorf("issue location = %q, want the DOI %q", located, tt.doi)
+				}
+			}
+			if tt.fixed != "" {
+				fixed := contents[:issue.Fix.Location.Start] + issue.Fix.Replacement + contents[issue.Fix.Location.End:]
+				if !strings.Contains(fixed, " "+tt.fixed+"\n") {
+					t.Errorf("fixed = %q, want it to contain %q", fixed, tt.fixed)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckDOIExists_Parentheses(t *testing.T) {
+	tests := []struct {
+		name      string
+		ref       string
+		issueType string
+		fixed     string
+	}{
+		{
+			name: "DOI in parentheses",
+			ref:  "A. Author, \\emph{Phys. Rev. Lett.}, vol. 1, p. 2, 2020 (DOI: 10.1103/PhysRevLett.1.2).",
+		},
+		{
+			name:      "DOI ending in a period in parentheses",
+			ref:       "A. Author, \\emph{Phys. Rev. Lett.}, vol. 1, p. 2, 2020 (DOI: 10.1103/PhysRevLett.1.2.)",
+			issueType: "DOI_ENDS_IN_PERIOD",
+			fixed:     "(DOI: 10.1103/PhysRevLett.1.2)",
+		},
+		{
+			name:      "Unmatched parenthesis",
+			ref:       "A. Author, \\emph{Phys. Rev. Lett.}, vol. 1, p. 2, 2020, DOI: 10.1103/PhysRevLett.1.2)",
+			issueType: "DOI_ENDS_IN_PARENTHESIS",
+			fixed:     "DOI: 10.1103/PhysRevLett.1.2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + "\n\\end{thebibliography}\n\\end{document}"
+			issue := CheckDOIExists(context.Background(), NewMemoryResolver("10.1103/PhysRevLett.1.2"), finder.Finder(structs.Request{Content: contents}).BibItems[0])
+			if tt.issueType == "" {
+				if issue != nil {
+					t.Fatalf("CheckDOIExists() = %v, want nil", issue)
+				}
+				return
+			}
+			if issue == nil || issue.Type != tt.issueType {
+				t.Fatalf("CheckDOIExists() = %v, want %s", issue, tt.issueType)
+			}
+			fixed := contents[:issue.Fix.Location.Start] + issue.Fix.Replacement + contents[issue.Fix.Location.End:]
+			if !strings.Contains(fixed, tt.fixed) {
+				t.Errorf("fixed = %q, want it to contain %q", fixed, tt.fixed)
+			}
+		})
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	handleAPI := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts[r.URL.Path]++
+		attempt := attempts[r.URL.Path]
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/api/handles/10.3204/PUBDB-2019-03613":
+			fmt.Fprint(w, `{"responseCode":1,"handle":"10.3204/PUBDB-2019-03613"}`)
+		case "/api/handles/10.1103/busy":
+			if attempt < 3 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			fmt.Fprint(w, `{"responseCode":1}`)
+		case "/api/handles/10.1103/overloaded":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"responseCode":100}`)
+		}
+	})
+	// a mirror without the handle API, which does not answer HEAD
+	mirror := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodHead:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/10.1103/found":
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		handler http.Handler
+		doi     string
+		want    bool
+		wantErr bool
+	}{
+		{name: "Registered", handler: handleAPI, doi: "10.3204/PUBDB-2019-03613", want: true},
+		{name: "Not registered", handler: handleAPI, doi: "10.1103/missing", want: false},
+		{name: "Busy then registered", handler: handleAPI, doi: "10.1103/busy", want: true},
+		{name: "Too many requests", handler: handleAPI, doi: "10.1103/overloaded", wantErr: true},
+		{name: "Mirror falls back to GET", handler: mirror, doi: "10.1103/found", want: true},
+		{name: "Mirror not found", handler: mirror, doi: "10.1103/missing", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			resolver := NewHTTPResolver(server.URL)
+			resolver.SetRetries(3, time.Millisecond)
+
+			got, err := resolver.Resolve(context.Background(), tt.doi)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckDOIExists_Deadline(t *testing.T) {
+	// a resolver that never answers, and keeps asking to be retried
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	resolver := NewHTTPResolver(server.URL)
+	resolver.SetDeadline(100 * time.Millisecond)
+
+	start := time.Now()
+	bibItem := structs.BibItem{Name: "a", Doi: "10.1103/PhysRevLett.1.1", NormalisedDoi: "10.1103/PhysRevLett.1.1"}
+	issue := CheckDOIExists(context.Background(), resolver, bibItem)
+	if issue == nil || issue.Type != "DOI_UNVERIFIED" {
+		t.Errorf("CheckDOIExists() = %v, want DOI_UNVERIFIED", issue)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("CheckDOIExists() took %v, want it to give up at the deadline", elapsed)
+	}
+}
+
+func TestCheckDOIExists_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	bibItem := structs.BibItem{Name: "a", Doi: "10.1103/PhysRevLett.1.1"}
+	if issue := CheckDOIExists(ctx, NewMemoryResolver(), bibItem); issue != nil {
+		t.Errorf("CheckDOIExists() = %v, want nil once cancelled", issue)
+	}
+}
+
+func TestRegistry_SetDOIResolver(t *testing.T) {
+	contents := finder.Finder(structs.Request{Content: "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nA. Author, \\emph{Phys. Rev. Lett.}, vol. 1, p. 1, 2020. \\url{doi:10.1103/PhysRevLett.1.1.}\n\\end{thebibliography}\n\\end{document}"})
+	lookup, _ := DefaultRegistry.Rule("DOI_LOOKUP")
+	registry := NewRegistry(lookup)
+	registry.SetDOIResolver(NewMemoryResolver("10.1103/PhysRevLett.1.1"))
+
+	issues := registry.Check(contents)
+	if len(issues) != 1 || issues[0].Type != "DOI_ENDS_IN_PERIOD" {
+		t.Errorf("Check() = %v, want one DOI_ENDS_IN_PERIOD", issues)
+	}
+}
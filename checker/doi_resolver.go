@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DOIOrgURL is the base URL DOIs are resolved against by default.
+const DOIOrgURL = "https://doi.org/"
+
+// handle API response codes, from the Handle.Net proxy documentation
+const (
+	handleFound         = 1
+	handleNotFound      = 100
+	handleValueNotFound = 200
+)
+
+// errNoHandleAPI is returned when the resolver's base URL does not serve the
+// handle API, so DOIs must be resolved by requesting them.
+var errNoHandleAPI = errors.New("no handle API")
+
+// DOIResolver reports whether a DOI is registered. It returns false with no
+// error only when the DOI is definitely not registered. An error means it
+// could not be found out, for example because the resolver could not be
+// reached or the context was cancelled. Resolvers are used by several
+// goroutines at once.
+type DOIResolver interface {
+	Resolve(ctx context.Context, doi string) (bool, error)
+}
+
+// HTTPResolver resolves DOIs with a DOI proxy, such as doi.org or an internal
+// mirror of it. Requests to each host are rate limited, and retried when the
+// proxy is busy.
+type HTTPResolver struct {
+	*retryingClient
+	baseURL string
+}
+
+// NewHTTPResolver creates a resolver for the proxy at baseURL, which the DOI
+// is appended to.
+func NewHTTPResolver(baseURL string) *HTTPResolver {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	client := newRetryingClient()
+	client.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		// Do not follow redirects
+		return http.ErrUseLastResponse
+	}
+	return &HTTPResolver{retryingClient: client, baseURL: baseURL}
+}
+
+// NewDOIOrgResolver creates a resolver for https://doi.org/.
+func NewDOIOrgResolver() *HTTPResolver {
+	return NewHTTPResolver(DOIOrgURL)
+}
+
+// Resolve asks the handle API, at api/handles/<doi>, whether the DOI is
+// registered. When the proxy does not serve the handle API the DOI itself is
+// requested, with HEAD and then GET for servers that do not answer HEAD. The
+// DOI is given up on with an error once the deadline passes.
+func (r *HTTPResolver) Resolve(ctx context.Context, doi string) (bool, error) {
+	ctx, cancel := r.lookup(ctx)
+	defer cancel()
+	path := escapeDOI(doi)
+	registered, err := r.resolveHandle(ctx, r.baseURL+"api/handles/"+path)
+	if !errors.Is(err, errNoHandleAPI) {
+		return registered, err
+	}
+	status := 0
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		status, _, err = r.request(ctx, method, r.baseURL+path)
+		if err != nil {
+			return false, fmt.Errorf("failed to check DOI: %w", err)
+		}
+		// Consider DOI valid if status is 200 OK or a redirect (3xx)
+		if status >= 200 && status < 400 {
+			return true, nil
+		}
+		if status == http.StatusNotFound {
+			return false, nil
+		}
+	}
+	return false, fmt.Errorf("failed to check DOI: %s returned %d", r.baseURL+path, status)
+}
+
+// resolveHandle reads a handle API response. It returns errNoHandleAPI when the
+// response is not from the handle API.
+func (r *HTTPResolver) resolveHandle(ctx context.Context, handleURL string) (bool, error) {
+	status, body, err := r.request(ctx, http.MethodGet, handleURL)
+	if err != nil {
+		return false, fmt.Errorf("failed to check DOI: %w", err)
+	}
+	var response struct {
+		ResponseCode *int `json:"responseCode"`
+	}
+	if (status != http.StatusOK && status != http.StatusNotFound) || json.Unmarshal(body, &response) != nil || response.ResponseCode == nil {
+		return false, errNoHandleAPI
+	}
+	switch *response.ResponseCode {
+	case handleFound, handleValueNotFound:
+		return true, nil
+	case handleNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check DOI: handle API returned response code %d", *response.ResponseCode)
+}
+
+// escapeDOI escapes the characters of a DOI that are not allowed in a URL
+// path, such as # and ?, keeping the slashes.
+func escapeDOI(doi string) string {
+	parts := strings.Split(doi, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// MemoryResolver resolves a fixed set of DOIs without network access, for
+// tests and offline runs. DOIs are compared ignoring case.
+type MemoryResolver struct {
+	dois map[string]bool
+	// Err, when set, is returned for every DOI.
+	Err error
+}
+
+func NewMemoryResolver(dois ...string) *MemoryResolver {
+	resolver := &MemoryResolver{dois: make(map[string]bool)}
+	for _, doi := range dois {
+		resolver.dois[finder.DOIKey(doi)] = true
+	}
+	return resolver
+}
+
+func (r *MemoryResolver) Resolve(ctx context.Context, doi string) (bool, error) {
+	if r.Err != nil {
+		return false, r.Err
+	}
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	return r.dois[finder.DOIKey(doi)], nil
+}
@@ -0,0 +1,273 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// doiSuggestionScore is the score, from 0 to 1, a search result must exceed
+// for its DOI to be suggested. Titles must match almost word for word, and the
+// first author must agree when it is known.
+const doiSuggestionScore = 0.85
+
+// ReferenceQuery is what a reference without a DOI is searched for by.
+type ReferenceQuery struct {
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   string `json:"year"`
+}
+
+// SearchResult is a work found by a search, and how well it matches the query,
+// from 0 to 1.
+type SearchResult struct {
+	DOI   string  `json:"doi"`
+	Title string  `json:"title"`
+	Score float64 `json:"score"`
+}
+
+// DOISearcher finds the works that best match a reference, best first. It
+// returns no results when nothing matches. Searchers are used by several
+// goroutines at once.
+type DOISearcher interface {
+	Search(ctx context.Context, query ReferenceQuery) ([]SearchResult, error)
+}
+
+// CorpusEntry is a reference with a DOI, from a paper that has been checked.
+type CorpusEntry struct {
+	DOI    string `json:"doi"`
+	Title  string `json:"title"`
+	Author string `json:"author"`
+	Year   string `json:"year"`
+}
+
+// CorpusIndex searches the references of past papers offline, by the words of
+// their titles. DOIs are compared ignoring case, and a DOI is only indexed
+// once.
+type CorpusIndex struct {
+	mu      sync.RWMutex
+	entries []CorpusEntry
+	dois    map[string]bool
+	words   map[string][]int
+}
+
+func NewCorpusIndex(entries ...CorpusEntry) *CorpusIndex {
+	index := &CorpusIndex{dois: make(map[string]bool), words: make(map[string][]int)}
+	for _, entry := range entries {
+		index.Add(entry)
+	}
+	return index
+}
+
+// LoadCorpusIndex reads an index saved by Save. A file that does not exist
+// is an empty index.
+func LoadCorpusIndex(fileName string) (*CorpusIndex, error) {
+	content, err := os.ReadFile(fileName)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewCorpusIndex(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []CorpusEntry
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus index %s: %w", fileName, err)
+	}
+	return NewCorpusIndex(entries...), nil
+}
+
+// Save writes the entries of the index to a JSON file, sorted by DOI.
+func (c *CorpusIndex) Save(fileName string) error {
+	c.mu.RLock()
+	entries := append([]CorpusEntry(nil), c.entries...)
+	c.mu.RUnlock()
+	sort.Slice(entries, func(i, j int) bool { return finder.DOIKey(entries[i].DOI) < finder.DOIKey(entries[j].DOI) })
+	content, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, content, 0644)
+}
+
+// Add indexes a reference. It returns false when the reference has no DOI or
+// title, or its DOI is already indexed.
+func (c *CorpusIndex) Add(entry CorpusEntry) bool {
+	key := finder.DOIKey(entry.DOI)
+	words := uniqueWords(entry.Title)
+	if key == "" || len(words) == 0 {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.dois[key] {
+		return false
+	}
+	c.dois[key] = true
+	c.entries = append(c.entries, entry)
+	for _, word := range words {
+		c.words[word] = append(c.words[word], len(c.entries)-1)
+	}
+	return true
+}
+
+// unindexedIssues are the issues showing the DOI of a bibitem is malformed, is
+// not registered or could not be checked, or belongs to another work.
+var unindexedIssues = map[string]bool{
+	"DOI_NOT_FOUND":                true,
+	"DOI_UNVERIFIED":               true,
+	"DOI_ENDS_IN_PERIOD":           true,
+	"DOI_ENDS_IN_PARENTHESIS":      true,
+	"DOI_ENDS_IN_PUNCTUATION":      true,
+	"JACOW_DOI_FORMAT":             true,
+	"JACOW_DOI_UNKNOWN_CONFERENCE": true,
+	"JACOW_DOI_UNKNOWN_YEAR":       true,
+	"JACOW_DOI_PAPER_ID":           true,
+	"METADATA_TITLE_MISMATCH":      true,
+	"METADATA_AUTHOR_MISMATCH":     true,
+}
+
+// AddBibItems indexes the bibitems of a paper that have a DOI and a title the
+// reference parser is confident of, given the issues found checking the paper.
+// Bibitems whose DOI was reported as malformed, not found or unverified are
+// skipped, so the index only suggests DOIs that were checked. It returns the
+// number added.
+func (c *CorpusIndex) AddBibItems(bibItems []structs.BibItem, issues []structs.Issue) int {
+	skipped := make(map[string]bool)
+	for _, issue := range issues {
+		if unindexedIssues[issue.Type] {
+			skipped[issue.Name] = true
+		}
+	}
+	added := 0
+	for _, bibItem := range bibItems {
+		if bibItem.NormalisedDoi == "" || bibItem.Parsed.Title.Confidence < metadataConfidence || skipped[bibItem.Name] {
+			continue
+		}
+		if c.Add(CorpusEntry{DOI: bibItem.NormalisedDoi, Title: bibItem.Parsed.Title.Value, Author: firstSurname(bibItem), Year: bibItem.Parsed.Year.Value}) {
+			added++
+		}
+	}
+	return added
+}
+
+// Len is the number of references indexed.
+func (c *CorpusIndex) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.entries)
+}
+
+// Search scores the references sharing a word of their title with the query.
+func (c *CorpusIndex) Search(ctx context.Context, query ReferenceQuery) ([]SearchResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	candidates := make(map[int]bool)
+	for _, word := range uniqueWords(query.Title) {
+		for _, i := range c.words[word] {
+			candidates[i] = true
+		}
+	}
+	var results []SearchResult
+	for i := range candidates {
+		entry := c.entries[i]
+		results = append(results, SearchResult{DOI: entry.DOI, Title: entry.Title, Score: matchScore(query, entry)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].DOI < results[j].DOI
+	})
+	return results, nil
+}
+
+// matchScore is how well a reference matches a query. The title counts for
+// most of the score, and the first author and year for the rest, so a title
+// on its own cannot pass doiSuggestionScore unless the query has no author or
+// year to compare.
+func matchScore(query ReferenceQuery, entry CorpusEntry) float64 {
+	score, weight := 0.7*titleScore(query.Title, entry.Title), 0.7
+	if query.Author != "" && entry.Author != "" {
+		weight += 0.2
+		if sameSurname(query.Author, entry.Author) {
+			score += 0.2
+		}
+	}
+	if query.Year != "" && entry.Year != "" {
+		weight += 0.1
+		if strings.TrimSpace(query.Year) == strings.TrimSpace(entry.Year) {
+			score += 0.1
+		}
+	}
+	return score / weight
+}
+
+// uniqueWords are the distinct words of a title, as it is indexed.
+func uniqueWords(title string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, word := range foldWords(title) {
+		if !seen[word] {
+			seen[word] = true
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// firstSurname is the surname of the first author of a bibitem, if the
+// reference parser is confident of it.
+func firstSurname(bibItem structs.BibItem) string {
+	if len(bibItem.Parsed.Authors) == 0 || bibItem.Parsed.Authors[0].Confidence < metadataConfidence {
+		return ""
+	}
+	return bibItem.Parsed.Authors[0].Surname
+}
+
+// doiSuggestion is how a DOI is written in a bibitem, or in a .bib entry.
+func doiSuggestion(bibItem structs.BibItem, doi string) string {
+	if bibItem.IsBibTeX() {
+		return "doi = {" + doi + "}"
+	}
+	return `\url{doi:` + doi + `}`
+}
+
+// CheckMissingDOI searches for the DOI of a bibitem that has none, by its
+// title, first author and year, and suggests the best result when its score
+// exceeds doiSuggestionScore.
+func CheckMissingDOI(ctx context.Context, searcher DOISearcher, bibItem structs.BibItem) *structs.Issue {
+	if bibItem.Doi != "" || searcher == nil || bibItem.Parsed.Title.Confidence < metadataConfidence {
+		return nil
+	}
+	query := ReferenceQuery{Title: bibItem.Parsed.Title.Value, Author: firstSurname(bibItem)}
+	if bibItem.Parsed.Year.Confidence >= metadataConfidence {
+		query.Year = bibItem.Parsed.Year.Value
+	}
+	results, err := searcher.Search(ctx, query)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error searching for the DOI of %s: %v", bibItem.Name, err)
+		}
+		return nil
+	}
+	if len(results) == 0 || results[0].Score <= doiSuggestionScore {
+		return nil
+	}
+	return &structs.Issue{
+		Name:       bibItem.Name,
+		Type:       "MISSING_DOI",
+		Location:   bibItem.Location,
+		Suggestion: doiSuggestion(bibItem, results[0].DOI),
+	}
+}
@@ -0,0 +1,82 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckMissingDOI(t *testing.T) {
+	index := NewCorpusIndex(
+		CorpusEntry{DOI: "10.1016/0168-583X(91)95671-Y", Title: "Approximations to multiple Coulomb scattering", Author: "Lynch", Year: "1991"},
+		CorpusEntry{DOI: "10.18429/JACoW-IPAC2023-MOPA001", Title: "Commissioning of the injector", Author: "Author", Year: "2023"},
+	)
+	tests := []struct {
+		name       string
+		ref        string
+		suggestion string
+	}{
+		{
+			name:       "Found",
+			ref:        "G. R. Lynch and O. I. Dahl, ``Approximations to multiple {C}oulomb scattering'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6--10, May 1991.",
+			suggestion: `\url{doi:10.1016/0168-583X(91)95671-Y}`,
+		},
+		{
+			name: "Same title by another author",
+			ref:  "B. Other, ``Commissioning of the injector'', in \\textit{Proc. IPAC'24}, Nashville, TN, USA, May 2024, pp. 1-4.",
+		},
+		{
+			name: "Similar title",
+			ref:  "G. R. Lynch, ``Multiple scattering of electrons in thick targets'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 60, pp. 1--5, 1991.",
+		},
+		{
+			name: "Already has a DOI",
+			ref:  "G. R. Lynch and O. I. Dahl, ``Approximations to multiple Coulomb scattering'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6--10, May 1991. \\url{doi:10.1016/0168-583X(91)95671-Y}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + "\n\\end{thebibliography}\n\\end{document}"
+			issue := CheckMissingDOI(context.Background(), index, finder.Finder(structs.Request{Content: contents}).BibItems[0])
+			if tt.suggestion == "" {
+				if issue != nil {
+					t.Fatalf("CheckMissingDOI() = %v, want nil", issue)
+				}
+				return
+			}
+			if issue == nil || issue.Type != "MISSING_DOI" || issue.Suggestion != tt.suggestion {
+				t.Fatalf("CheckMissingDOI() = %v, want MISSING_DOI with suggestion %q", issue, tt.suggestion)
+			}
+		})
+	}
+}
+
+func TestCorpusIndex_AddBibItems(t *testing.T) {
+	contents := finder.Finder(structs.Request{Content: "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nG. R. Lynch and O. I. Dahl, ``Approximations to multiple Coulomb scattering'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6--10, May 1991. \\url{doi:10.1016/0168-583X(91)95671-Y}\n\\bibitem{b}\nA. Author, ``A title without a DOI'', \\textit{Phys. Rev. Lett.}, vol. 1, p. 1, 2020.\n\\end{thebibliography}\n\\end{document}"})
+	index := NewCorpusIndex()
+	notFound := []structs.Issue{{Name: "a", Type: "DOI_NOT_FOUND"}}
+	if added := index.AddBibItems(contents.BibItems, notFound); added != 0 {
+		t.Fatalf("AddBibItems() = %d, want the reference whose DOI was not found skipped", added)
+	}
+	if added := index.AddBibItems(contents.BibItems, nil); added != 1 {
+		t.Fatalf("AddBibItems() = %d, want the one reference with a DOI", added)
+	}
+	if added := index.AddBibItems(contents.BibItems, nil); added != 0 {
+		t.Errorf("AddBibItems() again = %d, want the DOI only indexed once", added)
+	}
+
+	fileName := filepath.Join(t.TempDir(), "doi_index.json")
+	if err := index.Save(fileName); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := LoadCorpusIndex(fileName)
+	if err != nil {
+		t.Fatalf("LoadCorpusIndex() error = %v", err)
+	}
+	results, err := loaded.Search(context.Background(), ReferenceQuery{Title: "Approximations to multiple Coulomb scattering", Author: "Lynch", Year: "1991"})
+	if err != nil || len(results) != 1 || results[0].Score != 1 {
+		t.Errorf("Search() = %v, %v, want the saved reference with score 1", results, err)
+	}
+}
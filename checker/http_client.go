@@ -0,0 +1,156 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"golang.org/x/time/rate"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultRequestsPerSecond and defaultBurst limit the requests made to each
+// host, so checking a long bibliography is not refused.
+const (
+	defaultRequestsPerSecond = 10
+	defaultBurst             = 5
+)
+
+// defaultAttempts is how many times a request refused with 429 Too Many
+// Requests or a 5xx status is tried, waiting defaultBackoff before the second
+// attempt and twice as long before each one after, or as long as the server
+// asks up to maxRetryAfter.
+const (
+	defaultAttempts = 3
+	defaultBackoff  = 500 * time.Millisecond
+	maxRetryAfter   = 10 * time.Second
+)
+
+// defaultDeadline bounds a whole lookup, every attempt and wait included, so a
+// DOI that cannot be checked is given up on in bounded time. Each request is
+// given up on after defaultRequestTimeout, leaving time to try again.
+const (
+	defaultDeadline       = 20 * time.Second
+	defaultRequestTimeout = 10 * time.Second
+)
+
+// maxResponseSize is the most of a response body that is read.
+const maxResponseSize = 1 << 20
+
+// retryingClient makes HTTP requests that are rate limited for each host, and
+// retried while the server is busy. It is shared by the DOI resolver and the
+// metadata provider.
+type retryingClient struct {
+	client   *http.Client
+	header   http.Header
+	attempts int
+	backoff  time.Duration
+	deadline time.Duration
+	mu       sync.Mutex
+	limit    rate.Limit
+	burst    int
+	limiters map[string]*rate.Limiter
+}
+
+func newRetryingClient() *retryingClient {
+	return &retryingClient{
+		client:   &http.Client{Timeout: defaultRequestTimeout},
+		header:   make(http.Header),
+		attempts: defaultAttempts,
+		backoff:  defaultBackoff,
+		deadline: defaultDeadline,
+		limit:    defaultRequestsPerSecond,
+		burst:    defaultBurst,
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+// SetRateLimit changes the requests per second, and the burst of requests,
+// allowed to each host. Hosts already contacted keep their previous limit.
+func (c *retryingClient) SetRateLimit(limit rate.Limit, burst int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limit = limit
+	c.burst = burst
+}
+
+// SetRetries changes how many times a busy server is asked, and how long to
+// wait before asking again the first time.
+func (c *retryingClient) SetRetries(attempts int, backoff time.Duration) {
+	c.attempts = max(attempts, 1)
+	c.backoff = backoff
+}
+
+// SetDeadline changes how long a whole lookup may take, including retries.
+func (c *retryingClient) SetDeadline(deadline time.Duration) {
+	c.deadline = deadline
+}
+
+// lookup is the context of a single lookup, which is given up on once the
+// deadline passes.
+func (c *retryingClient) lookup(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, c.deadline)
+}
+
+func (c *retryingClient) limiter(host string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limiter, ok := c.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(c.limit, c.burst)
+		c.limiters[host] = limiter
+	}
+	return limiter
+}
+
+// request makes a request, retrying it with backoff while the server responds
+// with 429 Too Many Requests or a 5xx status, or cannot be reached. It returns
+// the status and body of the last response.
+func (c *retryingClient) request(ctx context.Context, method string, requestURL string) (int, []byte, error) {
+	delay := c.backoff
+	var lastErr error
+	for attempt := 0; attempt < c.attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+			case <-ctx.Done():
+				return 0, nil, ctx.Err()
+			}
+			delay *= 2
+		}
+		req, err := http.NewRequestWithContext(ctx, method, requestURL, nil)
+		if err != nil {
+			return 0, nil, err
+		}
+		for name, values := range c.header {
+			req.Header[name] = values
+		}
+		if err := c.limiter(req.URL.Host).Wait(ctx); err != nil {
+			return 0, nil, err
+		}
+		resp, err := c.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return 0, nil, ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("%s %s returned %d", method, requestURL, resp.StatusCode)
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+				delay = min(time.Duration(seconds)*time.Second, maxRetryAfter)
+			}
+			continue
+		}
+		if err != nil {
+			return 0, nil, err
+		}
+		return resp.StatusCode, body, nil
+	}
+	return 0, nil, fmt.Errorf("gave up after %d attempts: %w", c.attempts, lastErr)
+}
@@ -0,0 +1,265 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"github.com/dlclark/regexp2"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// arXivID finds anything written as an arXiv identifier, valid or not.
+	// Without a colon after arXiv the identifier must have a dot or slash, so
+	// the year in "arXiv 2019" is not taken for one.
+	arXivID = regexp2.MustCompile(`(?:\barXiv\s*:\s*|\barXiv\s*(?=[a-z\-]+(?:\.[A-Z]{2})?/|[0-9]+\.[0-9])|arxiv\.org/(?:abs|pdf)/)((?:[a-z\-]+(?:\.[A-Z]{2})?/)?[0-9][0-9.]*[0-9](?:v\d+)?)`, regexp2.IgnoreCase)
+	// newArXivID is the scheme used from April 2007, YYMM.NNNN, with five
+	// digit numbers from January 2015.
+	newArXivID = regexp.MustCompile(`^(\d{2})(\d{2})\.(\d{4,5})(v\d+)?$`)
+	// oldArXivID is the scheme used until March 2007, archive/YYMMNNN.
+	oldArXivID = regexp.MustCompile(`^([a-z\-]+)(\.[A-Z]{2})?/(\d{2})(\d{2})(\d{3})(v\d+)?$`)
+
+	// isbn finds anything written as an ISBN. Its digit groups may be
+	// separated by spaces, so it can run on into a year or page number, which
+	// isbnLength cuts off.
+	isbn = regexp2.MustCompile(`\bISBN(?:-1[03])?\s*:?\s*([0-9][0-9\- ]*[0-9Xx])\b`, 0)
+
+	bareURL      = regexp2.MustCompile(`(?<!\\(?:url|href)\s*\{\s*)\bhttps?://[^\s{}]*[^\s{}.,;)]`, 0)
+	doiURLScheme = regexp2.MustCompile(`(?<![/\w.])(?:www\.|dx\.)?doi\.org/(?=10\.)`, regexp2.IgnoreCase)
+)
+
+// arXivArchives are the archives of old scheme arXiv identifiers.
+var arXivArchives = map[string]bool{
+	"acc-phys": true, "adap-org": true, "alg-geom": true, "ao-sci": true, "astro-ph": true,
+	"atom-ph": true, "bayes-an": true, "chao-dyn": true, "chem-ph": true, "cmp-lg": true,
+	"comp-gas": true, "cond-mat": true, "cs": true, "dg-ga": true, "funct-an": true,
+	"gr-qc": true, "hep-ex": true, "hep-lat": true, "hep-ph": true, "hep-th": true,
+	"math": true, "math-ph": true, "mtrl-th": true, "nlin": true, "nucl-ex": true,
+	"nucl-th": true, "patt-sol": true, "physics": true, "plasm-ph": true, "q-alg": true,
+	"q-bio": true, "quant-ph": true, "solv-int": true, "supr-con": true,
+}
+
+// validateArXivID checks an arXiv identifier, without its arXiv: prefix. It
+// returns ARXIV_ID_FORMAT when it follows neither scheme, and ARXIV_ID_SCHEME
+// when it follows the wrong one for its date, or has too few or too many
+// digits for it. likely is the identifier that was most likely meant, or empty
+// when it cannot be told.
+func validateArXivID(id string) (issueType string, likely string) {
+	if groups := newArXivID.FindStringSubmatch(id); groups != nil {
+		year, _ := strconv.Atoi(groups[1])
+		month, _ := strconv.Atoi(groups[2])
+		yymm, number, version := year*100+month, groups[3], groups[4]
+		if month < 1 || month > 12 {
+			return "ARXIV_ID_FORMAT", ""
+		}
+		if yymm < 704 {
+			return "ARXIV_ID_SCHEME", ""
+		}
+		if yymm >= 1501 && len(number) == 4 {
+			return "ARXIV_ID_SCHEME", groups[1] + groups[2] + ".0" + number + version
+		}
+		if yymm < 1501 && len(number) == 5 {
+			if number[0] == '0' {
+				return "ARXIV_ID_SCHEME", groups[1] + groups[2] + "." + number[1:] + version
+			}
+			return "ARXIV_ID_SCHEME", ""
+		}
+		return "", ""
+	}
+	if groups := oldArXivID.FindStringSubmatch(id); groups != nil {
+		year, _ := strconv.Atoi(groups[3])
+		month, _ := strconv.Atoi(groups[4])
+		if !arXivArchives[groups[1]] || month < 1 || month > 12 {
+			return "ARXIV_ID_FORMAT", ""
+		}
+		// old identifiers start in August 1991, and years wrap at 2000
+		if year < 91 && year*100+month > 703 {
+			return "ARXIV_ID_SCHEME", ""
+		}
+		return "", ""
+	}
+	return "ARXIV_ID_FORMAT", ""
+}
+
+// checkArXivID validates the arXiv identifiers of a bibitem, or the eprint
+// field of a .bib entry.
+func checkArXivID(bibItem structs.BibItem) []structs.Issue {
+	var found []structs.ReferenceField
+	if bibItem.IsBibTeX() {
+		if bibItem.Parsed.ArXiv.Found() {
+			found = append(found, bibItem.Parsed.ArXiv)
+		}
+	} else {
+		for match, err := arXivID.FindStringMatch(bibItem.Ref); err == nil && match != nil; match, err = arXivID.FindNextMatch(match) {
+			group := match.Groups()[1]
+			found = append(found, structs.ReferenceField{Value: group.String(), Location: refMatchLocation(bibItem, group)})
+		}
+	}
+
+	var issues []structs.Issue
+	for _, id := range found {
+		issueType, likely := validateArXivID(id.Value)
+		if issueType == "" {
+			continue
+		}
+		issue := structs.Issue{Name: bibItem.Name, Type: issueType, Location: id.Location}
+		if likely != "" {
+			issue.Suggestion = "arXiv:" + likely
+			issue.Fix = &structs.Edit{Location: id.Location, Replacement: likely}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// isbnCheckDigit calculates the check digit of an ISBN-10 or ISBN-13 from its
+// other digits.
+func isbnCheckDigit(digits string) string {
+	sum := 0
+	if len(digits) == 9 {
+		for i, d := range digits {
+			sum += (10 - i) * int(d-'0')
+		}
+		check := (11 - sum%11) % 11
+		if check == 10 {
+			return "X"
+		}
+		return strconv.Itoa(check)
+	}
+	for i, d := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += weight * int(d-'0')
+	}
+	return strconv.Itoa((10 - sum%10) % 10)
+}
+
+// isbnLength is the length of the ISBN at the start of what was written, in
+// digit groups separated by spaces. It keeps the most groups that make the
+// digits of an ISBN-13, starting 978 or 979, or of an ISBN-10, or if none do,
+// the most that have no more digits than an ISBN-13.
+func isbnLength(written string) int {
+	length, longest, isbnDigits := 0, 0, 0
+	digits := ""
+	for _, group := range strings.SplitAfter(written, " ") {
+		digits += strings.ReplaceAll(strings.TrimSpace(group), "-", "")
+		if len(digits) > 13 && length != 0 {
+			break
+		}
+		length += len(group)
+		if len(digits) == 10 || (len(digits) == 13 && (strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979"))) {
+			isbnDigits = length
+		}
+		longest = length
+	}
+	if isbnDigits != 0 {
+		longest = isbnDigits
+	}
+	return len(strings.TrimRight(written[:longest], " "))
+}
+
+// validateISBN checks an ISBN as written, with or without hyphens. It returns
+// ISBN_FORMAT when it does not have the digits of an ISBN-10 or ISBN-13, and
+// ISBN_CHECKSUM when its check digit is wrong, with the ISBN the other digits
+// give.
+func validateISBN(written string) (issueType string, likely string) {
+	digits := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(written))
+	body := digits[:max(len(digits)-1, 0)]
+	if strings.ContainsAny(body, "X") ||
+		(len(digits) == 13 && (strings.HasSuffix(digits, "X") || !(strings.HasPrefix(digits, "978") || strings.HasPrefix(digits, "979")))) ||
+		(len(digits) != 10 && len(digits) != 13) {
+		return "ISBN_FORMAT", ""
+	}
+	check := isbnCheckDigit(body)
+	if digits[len(digits)-1:] == check {
+		return "", ""
+	}
+	return "ISBN_CHECKSUM", written[:len(written)-1] + check
+}
+
+// checkISBN validates the ISBNs of a bibitem, or the isbn field of a .bib
+// entry.
+func checkISBN(bibItem structs.BibItem) []structs.Issue {
+	var found []structs.ReferenceField
+	if bibItem.IsBibTeX() {
+		if field, ok := bibItem.Field("isbn"); ok {
+			found = append(found, structs.ReferenceField{Value: field.Value, Location: field.Location})
+		}
+	} else {
+		for match, err := isbn.FindStringMatch(bibItem.Ref); err == nil && match != nil; match, err = isbn.FindNextMatch(match) {
+			group := match.Groups()[1]
+			group.Length = isbnLength(group.String())
+			found = append(found, structs.ReferenceField{Value: group.String(), Location: refMatchLocation(bibItem, group)})
+		}
+	}
+
+	var issues []structs.Issue
+	for _, number := range found {
+		issueType, likely := validateISBN(strings.TrimSpace(number.Value))
+		if issueType == "" {
+			continue
+		}
+		issue := structs.Issue{Name: bibItem.Name, Type: issueType, Location: number.Location, Suggestion: likely}
+		if likely != "" {
+			issue.Fix = &structs.Edit{Location: number.Location, Replacement: likely}
+		}
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// checkURLNotWrapped reports links that are not wrapped in \url{}, such as
+// https://example.com/report.pdf, suggesting the link wrapped. Links to doi.org
+// are left to DOI_IS_URL, and .bib entries are formatted by the bibliography
+// style.
+func checkURLNotWrapped(bibItem structs.BibItem) []structs.Issue {
+	if bibItem.IsBibTeX() {
+		return nil
+	}
+	var issues []structs.Issue
+	for match, err := bareURL.FindStringMatch(bibItem.Ref); err == nil && match != nil; match, err = bareURL.FindNextMatch(match) {
+		if found, _ := doiIsUrl.MatchString(match.String()); found {
+			continue
+		}
+		location := refMatchLocation(bibItem, match.Group)
+		wrapped := `\url{` + match.String() + `}`
+		issues = append(issues, structs.Issue{
+			Name:       bibItem.Name,
+			Type:       "URL_NOT_WRAPPED",
+			Location:   location,
+			Suggestion: wrapped,
+			Fix:        &structs.Edit{Location: location, Replacement: wrapped},
+		})
+	}
+	return issues
+}
+
+// checkDOIURLNoScheme reports doi.org links written without https://, such as
+// \url{doi.org/10.1016/j.cpc.2020.107200}, suggesting the DOI with the doi:
+// prefix, or for .bib entries the bare DOI.
+func checkDOIURLNoScheme(bibItem structs.BibItem) []structs.Issue {
+	var found bool
+	var location *structs.Location
+	if bibItem.IsBibTeX() {
+		found, location = detectInField(doiURLScheme, bibItem, "doi")
+	} else {
+		found, location = detectInRef(doiURLScheme, bibItem)
+	}
+	if !found {
+		return nil
+	}
+	issue := structs.Issue{Name: bibItem.Name, Type: "DOI_URL_NO_SCHEME", Location: *location}
+	if bibItem.IsBibTeX() {
+		// the doi field holds the bare DOI, without a doi: prefix
+		issue.Suggestion = bibItem.NormalisedDoi
+		issue.Fix = &structs.Edit{Location: *location}
+	} else {
+		if bibItem.NormalisedDoi != "" {
+			issue.Suggestion = `\url{doi:` + bibItem.NormalisedDoi + `}`
+		}
+		issue.Fix = &structs.Edit{Location: *location, Replacement: "doi:"}
+	}
+	return []structs.Issue{issue}
+}
@@ -0,0 +1,179 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"strings"
+	"testing"
+)
+
+func TestValidateArXivID(t *testing.T) {
+	tests := []struct {
+		id        string
+		issueType string
+		likely    string
+	}{
+		{id: "2101.01234"},
+		{id: "0704.0001"},
+		{id: "1412.6980v9"},
+		{id: "hep-th/9901001"},
+		{id: "math.GT/0309136"},
+		{id: "1912.1234", issueType: "ARXIV_ID_SCHEME", likely: "1912.01234"},
+		{id: "1203.01234v2", issueType: "ARXIV_ID_SCHEME", likely: "1203.1234v2"},
+		{id: "1203.12345", issueType: "ARXIV_ID_SCHEME"},
+		{id: "0612.1234", issueType: "ARXIV_ID_SCHEME"},
+		{id: "hep-th/0801001", issueType: "ARXIV_ID_SCHEME"},
+		{id: "2113.01234", issueType: "ARXIV_ID_FORMAT"},
+		{id: "21.01234", issueType: "ARXIV_ID_FORMAT"},
+		{id: "made-up/9901001", issueType: "ARXIV_ID_FORMAT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			issueType, likely := validateArXivID(tt.id)
+			if issueType != tt.issueType || likely != tt.likely {
+				t.Errorf("validateArXivID() = %q, %q, want %q, %q", issueType, likely, tt.issueType, tt.likely)
+			}
+		})
+	}
+}
+
+func TestValidateISBN(t *testing.T) {
+	tests := []struct {
+		isbn      string
+		issueType string
+		likely    string
+	}{
+		{isbn: "978-3-16-148410-0"},
+		{isbn: "9783161484100"},
+		{isbn: "0-306-40615-2"},
+		{isbn: "0-8044-2957-X"},
+		{isbn: "978-3-16-148410-1", issueType: "ISBN_CHECKSUM", likely: "978-3-16-148410-0"},
+		{isbn: "0-306-40615-3", issueType: "ISBN_CHECKSUM", likely: "0-306-40615-2"},
+		{isbn: "0-8044-2957-1", issueType: "ISBN_CHECKSUM", likely: "0-8044-2957-X"},
+		{isbn: "978-3-16-14841-0", issueType: "ISBN_FORMAT"},
+		{isbn: "123-4-56-789012-8", issueType: "ISBN_FORMAT"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.isbn, func(t *testing.T) {
+			issueType, likely := validateISBN(tt.isbn)
+			if issueType != tt.issueType || likely != tt.likely {
+				t.Errorf("validateISBN() = %q, %q, want %q, %q", issueType, likely, tt.issueType, tt.likely)
+			}
+		})
+	}
+}
+
+func TestIdentifierRules(t *testing.T) {
+	tests := []struct {
+		name       string
+		ref        string
+		check      func(structs.BibItem) []structs.Issue
+		issueType  string
+		suggestion string
+		fixed      string
+	}{
+		{
+			name:       "arXiv identifier missing a digit",
+			ref:        "A. Author, ``A title'', arXiv:1912.1234.",
+			check:      checkArXivID,
+			issueType:  "ARXIV_ID_SCHEME",
+			suggestion: "arXiv:1912.01234",
+			fixed:      "arXiv:1912.01234.",
+		},
+		{
+			name:       "arXiv identifier without a colon",
+			ref:        "A. Author, ``A title'', arXiv 1912.1234, 2019.",
+			check:      checkArXivID,
+			issueType:  "ARXIV_ID_SCHEME",
+			suggestion: "arXiv:1912.01234",
+			fixed:      "arXiv 1912.01234, 2019.",
+		},
+		{
+			name:  "arXiv followed by a year",
+			ref:   "A. Author, ``A title'', arXiv 2019.",
+			check: checkArXivID,
+		},
+		{
+			name:  "Valid arXiv link",
+			ref:   "A. Author, ``A title'', \\url{https://arxiv.org/abs/2101.01234}.",
+			check: checkArXivID,
+		},
+		{
+			name:       "ISBN with wrong check digit",
+			ref:        "A. Author, \\emph{A Book}. Geneva, Switzerland: CERN, 2020, ISBN 978-3-16-148410-1.",
+			check:      checkISBN,
+			issueType:  "ISBN_CHECKSUM",
+			suggestion: "978-3-16-148410-0",
+			fixed:      "ISBN 978-3-16-148410-0.",
+		},
+		{
+			name:       "ISBN in groups with wrong check digit before a year",
+			ref:        "A. Author, \\emph{A Book}. Geneva, Switzerland: CERN, ISBN 978 3 16 148410 1 2020.",
+			check:      checkISBN,
+			issueType:  "ISBN_CHECKSUM",
+			suggestion: "978 3 16 148410 0",
+			fixed:      "ISBN 978 3 16 148410 0 2020.",
+		},
+		{
+			name:  "ISBN followed by a year",
+			ref:   "A. Author, \\emph{A Book}. Geneva, Switzerland: CERN, ISBN 978-0-306-40615-7 2005.",
+			check: checkISBN,
+		},
+		{
+			name:  "ISBN-10 followed by a page number",
+			ref:   "A. Author, \\emph{A Book}. Geneva, Switzerland: CERN, ISBN 0-306-40615-2 123.",
+			check: checkISBN,
+		},
+		{
+			name:       "Bare link",
+			ref:        "A. Author, ``A report'', 2020. https://example.com/report.pdf.",
+			check:      checkURLNotWrapped,
+			issueType:  "URL_NOT_WRAPPED",
+			suggestion: `\url{https://example.com/report.pdf}`,
+			fixed:      `2020. \url{https://example.com/report.pdf}.`,
+		},
+		{
+			name:  "Wrapped link",
+			ref:   "A. Author, ``A report'', 2020. \\url{https://example.com/report.pdf}",
+			check: checkURLNotWrapped,
+		},
+		{
+			name:  "Bare doi.org link is left to DOI_IS_URL",
+			ref:   "A. Author, ``A report'', 2020. https://doi.org/10.1000/182",
+			check: checkURLNotWrapped,
+		},
+		{
+			name:       "doi.org link without a scheme",
+			ref:        "A. Author, ``A title'', \\emph{Comput. Phys. Commun.}, vol. 258, p. 107200, 2021. \\url{doi.org/10.1016/j.cpc.2020.107200}",
+			check:      checkDOIURLNoScheme,
+			issueType:  "DOI_URL_NO_SCHEME",
+			suggestion: `\url{doi:10.1016/j.cpc.2020.107200}`,
+			fixed:      `\url{doi:10.1016/j.cpc.2020.107200}`,
+		},
+		{
+			name:  "doi.org link with a scheme",
+			ref:   "A. Author, ``A title'', 2021. \\url{https://dx.doi.org/10.1016/j.cpc.2020.107200}",
+			check: checkDOIURLNoScheme,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + "\n\\end{thebibliography}\n\\end{document}"
+			issues := tt.check(finder.Finder(structs.Request{Content: contents}).BibItems[0])
+			if tt.issueType == "" {
+				if len(issues) != 0 {
+					t.Fatalf("issues = %v, want none", issues)
+				}
+				return
+			}
+			if len(issues) != 1 || issues[0].Type != tt.issueType || issues[0].Suggestion != tt.suggestion {
+				t.Fatalf("issues = %v, want %s with suggestion %q", issues, tt.issueType, tt.suggestion)
+			}
+			fix := issues[0].Fix
+			fixed := contents[:fix.Location.Start] + fix.Replacement + contents[fix.Location.End:]
+			if !strings.Contains(fixed, tt.fixed) {
+				t.Errorf("fixed = %q, want it to contain %q", fixed, tt.fixed)
+			}
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package checker
+
+import "catscan-latex/structs"
+
+func detectIncludeErrors(issueType string, isError func(include structs.Include) bool) func(target Target) []structs.Issue {
+	return func(target Target) []structs.Issue {
+		var issues []structs.Issue
+		for _, include := range target.Contents.Includes {
+			if include.Error != "" && isError(include) {
+				issues = append(issues, structs.Issue{
+					Name:       include.Name,
+					Type:       issueType,
+					Location:   include.Location,
+					Suggestion: include.Error,
+				})
+			}
+		}
+		return issues
+	}
+}
+
+var includeNotFoundRule = NewRule("INCLUDE_NOT_FOUND", "File named by \\input, \\include or \\subfile is not in the project", structs.SeverityWarning, ScopeDocument,
+	detectIncludeErrors("INCLUDE_NOT_FOUND", func(include structs.Include) bool {
+		return include.File == ""
+	}))
+
+var includeCycleRule = NewRule("INCLUDE_CYCLE", "File is included by one of the files it includes", structs.SeverityError, ScopeDocument,
+	detectIncludeErrors("INCLUDE_CYCLE", func(include structs.Include) bool {
+		return include.File != ""
+	}))
@@ -1,16 +1,45 @@
 package checker
 
-import "catscan-latex/structs"
+import (
+	"catscan-latex/structs"
+	"context"
+)
 
 func GetIssues(result structs.Contents) []structs.Issue {
-	issues := make([]structs.Issue, 0)
-	for _, bibItem := range result.BibItems {
-		bibItemIssues := CheckBibItem(bibItem)
-		issues = append(issues, bibItemIssues...)
-		issue := CheckDOIExists(bibItem)
-		if issue != nil {
-			issues = append(issues, *issue)
+	profile, _ := LookupProfile(DefaultProfileName)
+	return GetIssuesWithProfile(context.Background(), result, profile)
+}
+
+// GetIssuesWithProfile checks the contents with the rules enabled by the
+// profile. DOI lookups are abandoned once ctx is done.
+func GetIssuesWithProfile(ctx context.Context, result structs.Contents, profile Profile) []structs.Issue {
+	issues := DefaultRegistry.CheckWithProfile(ctx, result, profile)
+	locateIssues(result, issues)
+	return issues
+}
+
+// locateIssues resolves the location of each issue, and its fix, to the file
+// it came from, and adds the line and column of the issue in that file.
+func locateIssues(result structs.Contents, issues []structs.Issue) {
+	indexes := make(map[string]structs.LineIndex)
+	for i := range issues {
+		issue := &issues[i]
+		issue.Location = result.Resolve(issue.Location)
+		if issue.Fix != nil {
+			fix := *issue.Fix
+			fix.Location = result.Resolve(issue.Fix.Location)
+			if fix.Location.End-fix.Location.Start == issue.Fix.Location.End-issue.Fix.Location.Start {
+				issue.Fix = &fix
+			} else {
+				// the edit spans more than one file
+				issue.Fix = nil
+			}
+		}
+		index, ok := indexes[issue.Location.File]
+		if !ok {
+			index = structs.NewLineIndex(result.FileContent(issue.Location.File))
+			indexes[issue.Location.File] = index
 		}
+		issue.Span = index.Span(issue.Location)
 	}
-	return issues
 }
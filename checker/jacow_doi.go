@@ -0,0 +1,261 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//go:embed jacow_conferences.yaml
+var jacowConferencesYaml []byte
+
+// jacowConference is a JACoW conference series, and the years its proceedings
+// were published with DOIs.
+type jacowConference struct {
+	Acronym string `yaml:"acronym" json:"acronym"`
+	Years   []int  `yaml:"years" json:"years"`
+}
+
+var jacowConferencesMu sync.RWMutex
+
+// jacowConferences are the conference series by their lower case acronym.
+var jacowConferences = loadJacowConferences(jacowConferencesYaml)
+
+func loadJacowConferences(data []byte) map[string]jacowConference {
+	var loaded []jacowConference
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		panic("checker: invalid jacow_conferences.yaml: " + err.Error())
+	}
+	conferences := make(map[string]jacowConference)
+	for _, c := range loaded {
+		conferences[strings.ToLower(c.Acronym)] = c
+	}
+	return conferences
+}
+
+// LoadJacowConferences reads a list of conference series from a YAML (.yaml,
+// .yml) or JSON file, in the format of jacow_conferences.yaml. A series that is
+// already known has its years replaced.
+func LoadJacowConferences(fileName string) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	var loaded []jacowConference
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &loaded)
+	default:
+		err = json.Unmarshal(content, &loaded)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse conferences in %s: %w", fileName, err)
+	}
+	jacowConferencesMu.Lock()
+	defer jacowConferencesMu.Unlock()
+	for _, c := range loaded {
+		if c.Acronym == "" {
+			return fmt.Errorf("conference in %s has no acronym", fileName)
+		}
+		jacowConferences[strings.ToLower(c.Acronym)] = c
+	}
+	return nil
+}
+
+// jacowDoiPartsRegex splits a JACoW DOI into its acronym, year and paper ID,
+// allowing for a missing or wrong separator and a two digit year.
+var jacowDoiPartsRegex = regexp.MustCompile(`(?i)^10\.18429/jacow[-_]?([a-z]+?)[-_]?(\d{4}|\d{2})[-_]?([a-z0-9]+)$`)
+
+// parseJacowDOI splits a JACoW DOI into its conference acronym, its year, with
+// a two digit year taken to be after 2000, and its paper ID.
+func parseJacowDOI(doi string) (string, int, string, bool) {
+	parts := jacowDoiPartsRegex.FindStringSubmatch(doi)
+	if parts == nil {
+		return "", 0, "", false
+	}
+	year, _ := strconv.Atoi(parts[2])
+	if year < 100 {
+		year += 2000
+	}
+	return parts[1], year, parts[3], true
+}
+
+// paperIDRegex is the grammar of JACoW paper IDs: the day, the session, which
+// some conferences number within the day, and the number of the paper in it,
+// such as MOPA012, THXD3, MO1AA01 or MO1BCO01.
+var paperIDRegex = regexp.MustCompile(`^(MO|TU|WE|TH|FR|SA|SU)\d?[A-Z]{1,6}\d{1,3}$`)
+
+// digitLookalikes are letters typed in place of the digits they look like.
+var digitLookalikes = map[byte]byte{'O': '0', 'I': '1', 'L': '1'}
+
+// ValidateJacowDOI checks a 10.18429/JACoW DOI names a known conference, a year
+// it was held and a valid paper ID, without looking it up. It returns the issue
+// type of the first problem found, and the DOI most likely meant when every
+// problem could be corrected. Both are empty when the DOI is valid, or is not a
+// JACoW DOI.
+func ValidateJacowDOI(doi string) (string, string) {
+	if !strings.HasPrefix(strings.ToLower(doi), jacowDoiPrefix) {
+		return "", ""
+	}
+	acronym, year, id, ok := parseJacowDOI(doi)
+	if !ok {
+		return "JACOW_DOI_FORMAT", ""
+	}
+	issueType := ""
+	problem := func(t string) {
+		if issueType == "" {
+			issueType = t
+		}
+	}
+
+	jacowConferencesMu.RLock()
+	series, ok := jacowConferences[strings.ToLower(acronym)]
+	if !ok {
+		problem("JACOW_DOI_UNKNOWN_CONFERENCE")
+		series, ok = closestJacowConference(acronym)
+	}
+	jacowConferencesMu.RUnlock()
+	if !ok {
+		return issueType, ""
+	}
+
+	if !containsYear(series.Years, year) {
+		problem("JACOW_DOI_UNKNOWN_YEAR")
+		year, ok = closestYear(series.Years, year)
+	}
+
+	paperID, valid := fixPaperID(id)
+	if !valid || paperID != strings.ToUpper(id) {
+		problem("JACOW_DOI_PAPER_ID")
+		ok = ok && valid
+	}
+
+	likely := fmt.Sprintf("%sJACoW-%s%d-%s", jacowDoiPrefix, series.Acronym, year, paperID)
+	if issueType == "" && !strings.EqualFold(likely, doi) {
+		problem("JACOW_DOI_FORMAT")
+	}
+	if issueType == "" || !ok {
+		return issueType, ""
+	}
+	return issueType, likely
+}
+
+// closestJacowConference finds the one series whose acronym is a typo of the
+// given acronym. The caller must hold jacowConferencesMu.
+func closestJacowConference(acronym string) (jacowConference, bool) {
+	var closest []jacowConference
+	best := 3
+	for key, c := range jacowConferences {
+		distance := editDistance(strings.ToLower(acronym), key)
+		if distance < best {
+			best, closest = distance, nil
+		}
+		if distance == best {
+			closest = append(closest, c)
+		}
+	}
+	if len(closest) != 1 || best >= len(acronym) {
+		return jacowConference{}, false
+	}
+	return closest[0], true
+}
+
+func containsYear(years []int, year int) bool {
+	for _, y := range years {
+		if y == year {
+			return true
+		}
+	}
+	return false
+}
+
+// closestYear finds the one year a conference was held which is a single typo,
+// such as a transposition, away from the given year.
+func closestYear(years []int, year int) (int, bool) {
+	var closest []int
+	for _, y := range years {
+		if editDistance(strconv.Itoa(y), strconv.Itoa(year)) == 1 {
+			closest = append(closest, y)
+		}
+	}
+	if len(closest) != 1 {
+		return 0, false
+	}
+	return closest[0], true
+}
+
+// fixPaperID upper cases a paper ID, and replaces the letters typed for digits
+// in the day and the paper number, so M0PA012 is MOPA012 and MOPA0O2 is
+// MOPA002. The number of the session, the 1 of MO1BCO01, is not the paper
+// number. It reports whether the result follows the grammar.
+func fixPaperID(id string) (string, bool) {
+	fixed := []byte(strings.ToUpper(id))
+	for i := 0; i < len(fixed) && i < 2; i++ {
+		if fixed[i] == '0' {
+			fixed[i] = 'O'
+		}
+	}
+	session := 2
+	if session < len(fixed) && fixed[session] >= '0' && fixed[session] <= '9' {
+		session++
+	}
+	if first := strings.IndexAny(string(fixed[min(session, len(fixed)):]), "0123456789"); first != -1 {
+		for i := session + first; i < len(fixed); i++ {
+			if digit, ok := digitLookalikes[fixed[i]]; ok {
+				fixed[i] = digit
+			}
+		}
+	}
+	return string(fixed), paperIDRegex.Match(fixed)
+}
+
+// editDistance counts the insertions, deletions, substitutions and
+// transpositions of adjacent characters needed to turn a into b.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// Check that a JACoW DOI names a conference, year and paper that can exist
+// e.g. 10.18429/JACoW-IPCA2023-M0PA012 rather than 10.18429/JACoW-IPAC2023-MOPA012
+func checkJacowDOI(bibItem structs.BibItem) []structs.Issue {
+	issueType, likely := ValidateJacowDOI(bibItem.NormalisedDoi)
+	if issueType == "" {
+		return nil
+	}
+	issue := structs.Issue{Name: bibItem.Name, Type: issueType, Location: bibItem.Location, Suggestion: likely}
+	if location := bibItem.NormalisedDoiLocation; location.End != 0 {
+		issue.Location = location
+		if likely != "" {
+			issue.Fix = &structs.Edit{Location: location, Replacement: likely}
+		}
+	}
+	return []structs.Issue{issue}
+}
@@ -0,0 +1,129 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateJacowDOI(t *testing.T) {
+	tests := []struct {
+		name      string
+		doi       string
+		issueType string
+		likely    string
+	}{
+		{name: "Valid", doi: "10.18429/JACoW-IPAC2023-MOPA012"},
+		{name: "Valid oral", doi: "10.18429/JACoW-LINAC2022-THXD3"},
+		{name: "Case does not matter", doi: "10.18429/jacow-pcapac2022-tupo001"},
+		{name: "Valid numbered session", doi: "10.18429/JACoW-LINAC2022-MO1AA01"},
+		{name: "Valid numbered session ending in O", doi: "10.18429/JACoW-ICALEPCS2023-MO1BCO01"},
+		{
+			name:      "Letter I in the paper number of a numbered session",
+			doi:       "10.18429/JACoW-LINAC2022-MO1AA0I",
+			issueType: "JACOW_DOI_PAPER_ID",
+			likely:    "10.18429/JACoW-LINAC2022-MO1AA01",
+		},
+		{name: "Not a JACoW DOI", doi: "10.1103/PhysRevLett.1.1"},
+		{
+			name:      "No year",
+			doi:       "10.18429/JACoW-IPAC-MOPA012",
+			issueType: "JACOW_DOI_FORMAT",
+		},
+		{
+			name:      "Two digit year",
+			doi:       "10.18429/JACoW-IPAC23-MOPA012",
+			issueType: "JACOW_DOI_FORMAT",
+			likely:    "10.18429/JACoW-IPAC2023-MOPA012",
+		},
+		{
+			name:      "Transposed acronym",
+			doi:       "10.18429/JACoW-IPCA2023-MOPA012",
+			issueType: "JACOW_DOI_UNKNOWN_CONFERENCE",
+			likely:    "10.18429/JACoW-IPAC2023-MOPA012",
+		},
+		{
+			name:      "Unknown conference",
+			doi:       "10.18429/JACoW-XYZZY2023-MOPA012",
+			issueType: "JACOW_DOI_UNKNOWN_CONFERENCE",
+		},
+		{
+			name:      "Transposed year",
+			doi:       "10.18429/JACoW-ICALEPCS2032-MOPA012",
+			issueType: "JACOW_DOI_UNKNOWN_YEAR",
+			likely:    "10.18429/JACoW-ICALEPCS2023-MOPA012",
+		},
+		{
+			name:      "Year not held",
+			doi:       "10.18429/JACoW-LINAC2020-MOPA012",
+			issueType: "JACOW_DOI_UNKNOWN_YEAR",
+		},
+		{
+			name:      "Letter O in paper number",
+			doi:       "10.18429/JACoW-IPAC2023-MOPA0O2",
+			issueType: "JACOW_DOI_PAPER_ID",
+			likely:    "10.18429/JACoW-IPAC2023-MOPA002",
+		},
+		{
+			name:      "Digit zero in day",
+			doi:       "10.18429/JACoW-IPAC2023-M0PA012",
+			issueType: "JACOW_DOI_PAPER_ID",
+			likely:    "10.18429/JACoW-IPAC2023-MOPA012",
+		},
+		{
+			name:      "No day in paper ID",
+			doi:       "10.18429/JACoW-IPAC2023-PA012",
+			issueType: "JACOW_DOI_PAPER_ID",
+		},
+		{
+			name:      "Several typos",
+			doi:       "10.18429/JACoW-ICALPECS2032-M0PA012",
+			issueType: "JACOW_DOI_UNKNOWN_CONFERENCE",
+			likely:    "10.18429/JACoW-ICALEPCS2023-MOPA012",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issueType, likely := ValidateJacowDOI(tt.doi)
+			if issueType != tt.issueType || likely != tt.likely {
+				t.Errorf("ValidateJacowDOI() = %q, %q, want %q, %q", issueType, likely, tt.issueType, tt.likely)
+			}
+		})
+	}
+}
+
+func TestCheckJacowDOI(t *testing.T) {
+	contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nA. Author, \"A title\", in \\emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1--4. \\url{doi:10.18429/JACoW-IPAC2023-M0PA012}.\n\\end{thebibliography}\n\\end{document}"
+	issues := checkJacowDOI(finder.Finder(structs.Request{Content: contents}).BibItems[0])
+	if len(issues) != 1 || issues[0].Type != "JACOW_DOI_PAPER_ID" {
+		t.Fatalf("checkJacowDOI() = %v, want one JACOW_DOI_PAPER_ID", issues)
+	}
+	fix := issues[0].Fix
+	if fix == nil {
+		t.Fatalf("checkJacowDOI() has no fix")
+	}
+	fixed := contents[:fix.Location.Start] + fix.Replacement + contents[fix.Location.End:]
+	want := "\\url{doi:10.18429/JACoW-IPAC2023-MOPA012}."
+	if got := fixed[fix.Location.Start-len(`\url{doi:`) : fix.Location.Start+len(fix.Replacement)+2]; got != want {
+		t.Errorf("fixed = %q, want %q", got, want)
+	}
+}
+
+func TestLoadJacowConferences(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "conferences.yaml")
+	if err := os.WriteFile(fileName, []byte("- acronym: TESTCONF\n  years: [2030]\n"), 0644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if issueType, _ := ValidateJacowDOI("10.18429/JACoW-TESTCONF2030-MOPA001"); issueType != "JACOW_DOI_UNKNOWN_CONFERENCE" {
+		t.Fatalf("ValidateJacowDOI() before loading = %q", issueType)
+	}
+	if err := LoadJacowConferences(fileName); err != nil {
+		t.Fatalf("LoadJacowConferences() error = %v", err)
+	}
+	if issueType, _ := ValidateJacowDOI("10.18429/JACoW-TESTCONF2030-MOPA001"); issueType != "" {
+		t.Errorf("ValidateJacowDOI() after loading = %q, want valid", issueType)
+	}
+}
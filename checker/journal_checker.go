@@ -0,0 +1,216 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	_ "embed"
+	"gopkg.in/yaml.v3"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed journals.yaml
+var journalsYaml []byte
+
+type journal struct {
+	Name         string `yaml:"name"`
+	Abbreviation string `yaml:"abbreviation"`
+}
+
+var journals = loadJournals(journalsYaml)
+
+// journalAbbreviations finds the abbreviation of a journal by its normalised
+// full name.
+var journalAbbreviations = func() map[string]string {
+	abbreviations := make(map[string]string)
+	for _, j := range journals {
+		abbreviations[normaliseJournalName(j.Name)] = j.Abbreviation
+	}
+	return abbreviations
+}()
+
+// journalNames are the full and abbreviated names of every journal, longest
+// first so "Phys. Rev. Lett." is found before "Phys. Rev.".
+var journalNames = func() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, j := range journals {
+		for _, name := range []string{j.Name, j.Abbreviation} {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return names
+}()
+
+// knownJournals are the normalised full and abbreviated names of every journal.
+var knownJournals = func() map[string]bool {
+	known := make(map[string]bool)
+	for _, j := range journals {
+		known[normaliseJournalName(j.Name)] = true
+		known[normaliseJournalName(j.Abbreviation)] = true
+	}
+	return known
+}()
+
+// isJournal reports whether a name is the full or abbreviated name of a
+// journal in journals.yaml.
+func isJournal(name string) bool {
+	return knownJournals[normaliseJournalName(name)]
+}
+
+func loadJournals(data []byte) []journal {
+	var loaded []journal
+	if err := yaml.Unmarshal(data, &loaded); err != nil {
+		panic("checker: invalid journals.yaml: " + err.Error())
+	}
+	return loaded
+}
+
+// normaliseJournalName lower cases a name, and removes the punctuation and
+// words that vary between ways of writing it, such as "The" and "&".
+func normaliseJournalName(name string) string {
+	name = strings.NewReplacer(`\&`, " and ", "&", " and ", "~", " ").Replace(strings.ToLower(name))
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ':' || r == '-' || r == '.' || r == '{' || r == '}'
+	})
+	if len(words) > 0 && words[0] == "the" {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+var italicOpening = regexp.MustCompile(validOptions)
+
+// italicSpans finds the text in italics, the arguments of the commands in
+// validCommands, as locations in the text.
+func italicSpans(text string) []structs.Location {
+	var spans []structs.Location
+	for _, match := range italicOpening.FindAllStringIndex(text, -1) {
+		brace := match[1] - 1
+		if text[match[0]] == '{' {
+			brace = match[0]
+		}
+		depth := 0
+		for i := brace; i < len(text); i++ {
+			if text[i] == '{' {
+				depth++
+			} else if text[i] == '}' {
+				depth--
+				if depth == 0 {
+					spans = append(spans, structs.Location{Start: match[1], End: i})
+					break
+				}
+			}
+		}
+	}
+	return spans
+}
+
+// journalOf finds the journal or proceedings of a bibitem, as the location and
+// text it was found at. When the reference parser did not find one, a known
+// journal name is looked for outside the title.
+func journalOf(bibItem structs.BibItem) (structs.Location, string, bool) {
+	if journal := bibItem.Parsed.Journal; journal.Found() {
+		return journal.Location, journal.Value, true
+	}
+	title := bibItem.Parsed.Title.Location
+	for _, name := range journalNames {
+		from := 0
+		for {
+			index := strings.Index(bibItem.Ref[from:], name)
+			if index == -1 {
+				break
+			}
+			index += from
+			from = index + len(name)
+			if from < len(bibItem.Ref) && unicode.IsLetter(rune(bibItem.Ref[from])) {
+				continue
+			}
+			start := index
+			if strings.HasSuffix(bibItem.Ref[:start], "The ") {
+				start -= len("The ")
+			}
+			location := bibItem.RefLocation(start, from)
+			if bibItem.Parsed.Title.Found() && location.Start < title.End && title.Start < location.End {
+				continue
+			}
+			return location, bibItem.Ref[start:from], true
+		}
+	}
+	return structs.Location{}, "", false
+}
+
+// journalEdit replaces the name of a journal with its abbreviation, wrapping it
+// in \emph when it is not already italic.
+func journalEdit(location structs.Location, name string, italic bool) *structs.Edit {
+	if abbreviation, ok := journalAbbreviations[normaliseJournalName(name)]; ok {
+		name = abbreviation
+	}
+	if !italic {
+		name = `\emph{` + name + `}`
+	}
+	return &structs.Edit{Location: location, Replacement: name}
+}
+
+// Check that the journal or proceedings is in italics, and that a journal is
+// abbreviated. When both are wrong the two issues suggest the same edit.
+// e.g. Physical Review Letters rather than \emph{Phys. Rev. Lett.}
+func checkJournal(bibItem structs.BibItem) []structs.Issue {
+	if bibItem.IsBibTeX() {
+		return checkBibTeXJournal(bibItem)
+	}
+	location, name, ok := journalOf(bibItem)
+	if !ok {
+		return nil
+	}
+	text, ok := originalText(bibItem, location)
+	if !ok {
+		return nil
+	}
+
+	italic := false
+	for _, span := range italicSpans(bibItem.OriginalText) {
+		if location.Start-bibItem.Location.Start >= span.Start && location.End-bibItem.Location.Start <= span.End {
+			italic = true
+		}
+	}
+	var issues []structs.Issue
+	if !italic {
+		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: "JOURNAL_NOT_ITALIC", Location: location, Fix: journalEdit(location, text, false)})
+	}
+	if abbreviation, ok := journalAbbreviations[normaliseJournalName(name)]; ok && abbreviation != name {
+		issues = append(issues, structs.Issue{
+			Name:       bibItem.Name,
+			Type:       "JOURNAL_NOT_ABBREVIATED",
+			Location:   location,
+			Suggestion: abbreviation,
+			Fix:        journalEdit(location, text, italic),
+		})
+	}
+	return issues
+}
+
+// checkBibTeXJournal checks the journal field of a .bib entry is abbreviated.
+// The style makes it italic.
+func checkBibTeXJournal(bibItem structs.BibItem) []structs.Issue {
+	field, ok := bibItem.Field("journal")
+	if !ok {
+		return nil
+	}
+	abbreviation, ok := journalAbbreviations[normaliseJournalName(field.Value)]
+	if !ok || abbreviation == strings.TrimSpace(field.Value) {
+		return nil
+	}
+	return []structs.Issue{{
+		Name:       bibItem.Name,
+		Type:       "JOURNAL_NOT_ABBREVIATED",
+		Location:   field.Location,
+		Suggestion: abbreviation,
+		Fix:        &structs.Edit{Location: field.Location, Replacement: abbreviation},
+	}}
+}
@@ -0,0 +1,83 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckJournal(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected []string
+		fixed    string
+	}{
+		{
+			name:     "Italic and abbreviated",
+			ref:      `J. Smith, "A title", \emph{Phys. Rev. Accel. Beams}, vol. 1, p. 2, 2020.`,
+			expected: []string{},
+		},
+		{
+			name:     "Italic but not abbreviated",
+			ref:      `J. Smith, "A title", \textit{IEEE Transactions on Applied Superconductivity}, vol. 1, p. 2, 2020.`,
+			expected: []string{"JOURNAL_NOT_ABBREVIATED"},
+			fixed:    `J. Smith, "A title", \textit{IEEE Trans. Appl. Supercond.}, vol. 1, p. 2, 2020.`,
+		},
+		{
+			name:     "Abbreviated but not italic",
+			ref:      `J. Smith, "A title", Phys. Rev. Lett., vol. 1, p. 2, 2020.`,
+			expected: []string{"JOURNAL_NOT_ITALIC"},
+			fixed:    `J. Smith, "A title", \emph{Phys. Rev. Lett.}, vol. 1, p. 2, 2020.`,
+		},
+		{
+			name:     "Neither italic nor abbreviated",
+			ref:      `J. Smith, "A title", The Physical Review Letters, vol. 1, p. 2, 2020.`,
+			expected: []string{"JOURNAL_NOT_ITALIC", "JOURNAL_NOT_ABBREVIATED"},
+			fixed:    `J. Smith, "A title", \emph{Phys. Rev. Lett.}, vol. 1, p. 2, 2020.`,
+		},
+		{
+			name:     "Proceedings not italic",
+			ref:      `J. Smith, "A title", in Proc. IPAC'23, Venice, Italy, May 2023, pp. 1-4.`,
+			expected: []string{"JOURNAL_NOT_ITALIC"},
+			fixed:    `J. Smith, "A title", in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1-4.`,
+		},
+		{
+			name:     "Journal name in the title",
+			ref:      `J. Smith, "Fifty years of Physical Review Letters", \emph{Phys. Today}, 2008.`,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + "\n\\end{thebibliography}\n\\end{document}"
+			bibItems := finder.Finder(structs.Request{Content: contents}).BibItems
+			issues := checkJournal(bibItems[0])
+
+			got := make([]string, 0)
+			for _, issue := range issues {
+				got = append(got, issue.Type)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("checkJournal() = %v, want %v", got, tt.expected)
+			}
+			for _, issue := range issues {
+				edit := issue.Fix
+				if fixed := contents[:edit.Location.Start] + edit.Replacement + contents[edit.Location.End:]; fixed != strings.Replace(contents, tt.ref, tt.fixed, 1) {
+					t.Errorf("%s fixed contents = %q", issue.Type, fixed)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckJournal_BibTeX(t *testing.T) {
+	item := finder.ParseBibTeX("refs.bib", "@article{a, journal = {Nuclear Instruments and Methods in Physics Research Section A}}")[0]
+	issues := checkJournal(item)
+	if len(issues) != 1 || issues[0].Suggestion != "Nucl. Instrum. Methods Phys. Res., Sect. A" {
+		t.Errorf("checkJournal() = %v", issues)
+	}
+}
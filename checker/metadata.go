@@ -0,0 +1,138 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// CrossrefURL is the base URL of the Crossref REST API.
+const CrossrefURL = "https://api.crossref.org/"
+
+// Metadata is the registered metadata of a DOI, in the shape of a work from the
+// Crossref REST API, so fixtures can be saved from api.crossref.org/works/<doi>.
+type Metadata struct {
+	DOI             string           `json:"DOI"`
+	Title           []string         `json:"title"`
+	Author          []MetadataAuthor `json:"author"`
+	ContainerTitle  []string         `json:"container-title,omitempty"`
+	Volume          string           `json:"volume,omitempty"`
+	Page            string           `json:"page,omitempty"`
+	Issued          MetadataDate     `json:"issued"`
+	PublishedPrint  MetadataDate     `json:"published-print"`
+	PublishedOnline MetadataDate     `json:"published-online"`
+}
+
+type MetadataAuthor struct {
+	Given  string `json:"given,omitempty"`
+	Family string `json:"family,omitempty"`
+	// Name is set instead of Given and Family for organisations.
+	Name string `json:"name,omitempty"`
+}
+
+// MetadataDate is a partial date, as year, month and day, of which only the
+// year may be known.
+type MetadataDate struct {
+	DateParts [][]int `json:"date-parts"`
+}
+
+// Year is the year of the date, or 0 when it is not known.
+func (d MetadataDate) Year() int {
+	if len(d.DateParts) == 0 || len(d.DateParts[0]) == 0 {
+		return 0
+	}
+	return d.DateParts[0][0]
+}
+
+// MetadataProvider looks up the registered metadata of a DOI. It returns nil
+// with no error when the DOI is not registered with it. Providers are used by
+// several goroutines at once.
+type MetadataProvider interface {
+	Metadata(ctx context.Context, doi string) (*Metadata, error)
+}
+
+// CrossrefProvider looks up metadata with the Crossref REST API, or a server
+// with the same API. Requests are rate limited and retried like the DOI
+// resolver's.
+type CrossrefProvider struct {
+	*retryingClient
+	baseURL string
+}
+
+// NewCrossrefProvider creates a provider for the API at baseURL. When mailto is
+// set it is sent in the User-Agent, so Crossref serves the requests from its
+// polite pool.
+func NewCrossrefProvider(baseURL string, mailto string) *CrossrefProvider {
+	if !strings.HasSuffix(baseURL, "/") {
+		baseURL += "/"
+	}
+	client := newRetryingClient()
+	if mailto != "" {
+		client.header.Set("User-Agent", "catscan-latex (mailto:"+mailto+")")
+	}
+	return &CrossrefProvider{retryingClient: client, baseURL: baseURL}
+}
+
+func (p *CrossrefProvider) Metadata(ctx context.Context, doi string) (*Metadata, error) {
+	ctx, cancel := p.lookup(ctx)
+	defer cancel()
+	status, body, err := p.request(ctx, http.MethodGet, p.baseURL+"works/"+escapeDOI(doi))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch metadata: %w", err)
+	}
+	if status == http.StatusNotFound {
+		return nil, nil
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch metadata: works/%s returned %d", doi, status)
+	}
+	var response struct {
+		Message *Metadata `json:"message"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata of %s: %w", doi, err)
+	}
+	return response.Message, nil
+}
+
+// MetadataFixture holds the metadata of a fixed set of DOIs, for tests and
+// offline runs. DOIs are compared ignoring case.
+type MetadataFixture struct {
+	works map[string]Metadata
+}
+
+func NewMetadataFixture(works ...Metadata) *MetadataFixture {
+	fixture := &MetadataFixture{works: make(map[string]Metadata)}
+	for _, work := range works {
+		fixture.works[finder.DOIKey(work.DOI)] = work
+	}
+	return fixture
+}
+
+// LoadMetadataFixture reads a fixture from a JSON array of Crossref works.
+func LoadMetadataFixture(fileName string) (*MetadataFixture, error) {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	var works []Metadata
+	if err := json.Unmarshal(content, &works); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata fixture %s: %w", fileName, err)
+	}
+	return NewMetadataFixture(works...), nil
+}
+
+func (f *MetadataFixture) Metadata(ctx context.Context, doi string) (*Metadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	work, ok := f.works[finder.DOIKey(doi)]
+	if !ok {
+		return nil, nil
+	}
+	return &work, nil
+}
@@ -0,0 +1,169 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"context"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// metadataConfidence is how confident the reference parser must be in a field
+// before it is compared with the registered metadata.
+const metadataConfidence = 0.5
+
+// titleSimilarity is the fraction of words two titles must share to be the
+// same title, allowing for abbreviations and differences in punctuation.
+const titleSimilarity = 0.6
+
+var (
+	htmlTagRegex      = regexp.MustCompile(`<[^>]*>`)
+	latexCommandRegex = regexp.MustCompile(`\\(?:[a-zA-Z]+|.)`)
+)
+
+// accentFolder replaces accented letters with the letters they are written
+// with in ASCII, so Müller and M\"uller are the same name.
+var accentFolder = func() *strings.Replacer {
+	var pairs []string
+	for plain, accented := range map[string]string{
+		"a": "áàâäãåāą", "c": "çčć", "e": "éèêëēęě", "i": "íìîïī", "l": "ł",
+		"n": "ñńň", "o": "óòôöõøō", "r": "ř", "s": "šś", "u": "úùûüūů",
+		"y": "ýÿ", "z": "žźż",
+	} {
+		for _, r := range accented {
+			pairs = append(pairs, string(r), plain)
+		}
+	}
+	return strings.NewReplacer(append(pairs, "ß", "ss")...)
+}()
+
+// foldWords splits text, written in LaTeX or with HTML tags as in Crossref
+// titles, into lower case words without accents.
+func foldWords(text string) []string {
+	text = htmlTagRegex.ReplaceAllString(text, " ")
+	text = latexCommandRegex.ReplaceAllStringFunc(text, func(command string) string {
+		if command == `\&` {
+			return " and "
+		}
+		return ""
+	})
+	text = strings.NewReplacer("{", "", "}", "", "&", " and ").Replace(text)
+	text = accentFolder.Replace(strings.ToLower(text))
+	return strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+// titleScore is the fraction of words two titles share, from 0 to 1.
+func titleScore(a string, b string) float64 {
+	aWords, bWords := foldWords(a), foldWords(b)
+	if len(aWords) == 0 || len(bWords) == 0 {
+		return 0
+	}
+	counts := make(map[string]int)
+	for _, word := range bWords {
+		counts[word]++
+	}
+	shared := 0
+	for _, word := range aWords {
+		if counts[word] > 0 {
+			counts[word]--
+			shared++
+		}
+	}
+	return float64(2*shared) / float64(len(aWords)+len(bWords))
+}
+
+// sameTitle reports whether two titles share enough of their words. A title
+// without words cannot be compared, so is the same.
+func sameTitle(written string, registered string) bool {
+	if len(foldWords(written)) == 0 || len(foldWords(registered)) == 0 {
+		return true
+	}
+	return titleScore(written, registered) >= titleSimilarity
+}
+
+// sameSurname reports whether two surnames are the same, ignoring accents. A
+// surname written without its particle, Meer for van der Meer, is the same.
+func sameSurname(written string, registered string) bool {
+	writtenName, registeredName := strings.Join(foldWords(written), " "), strings.Join(foldWords(registered), " ")
+	return writtenName == registeredName || strings.HasSuffix(" "+registeredName, " "+writtenName) || strings.HasSuffix(" "+writtenName, " "+registeredName)
+}
+
+// firstPage is the page a page range starts at, 12 of 12--15.
+func firstPage(pages string) string {
+	fields := strings.FieldsFunc(pages, func(r rune) bool { return r == '-' || r == '–' || r == '—' || r == ',' })
+	if len(fields) == 0 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(fields[0]))
+}
+
+// registeredTitle is the title of a work without its HTML tags.
+func registeredTitle(metadata *Metadata) string {
+	if len(metadata.Title) == 0 {
+		return ""
+	}
+	return strings.Join(strings.Fields(htmlTagRegex.ReplaceAllString(metadata.Title[0], "")), " ")
+}
+
+// registeredSurname is the family name of the first author of a work.
+func registeredSurname(metadata *Metadata) string {
+	if len(metadata.Author) == 0 {
+		return ""
+	}
+	if metadata.Author[0].Family != "" {
+		return metadata.Author[0].Family
+	}
+	return metadata.Author[0].Name
+}
+
+// CheckMetadata compares the title, first author, year, volume and pages of a
+// bibitem with the metadata registered for its DOI, and reports those that
+// differ, with the registered value as the suggestion. Only fields the
+// reference parser is confident of, and that are registered, are compared.
+// The year matches any of the years the work was issued, printed or published
+// online, as references cite either.
+func CheckMetadata(ctx context.Context, provider MetadataProvider, bibItem structs.BibItem) []structs.Issue {
+	doi := bibItem.NormalisedDoi
+	if doi == "" || provider == nil {
+		return nil
+	}
+	metadata, err := provider.Metadata(ctx, doi)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("Error fetching metadata of DOI %s: %v", doi, err)
+		}
+		return nil
+	}
+	if metadata == nil {
+		return nil
+	}
+	parsed := bibItem.Parsed
+	var issues []structs.Issue
+	mismatch := func(issueType string, location structs.Location, expected string) {
+		issues = append(issues, structs.Issue{Name: bibItem.Name, Type: issueType, Location: location, Suggestion: expected})
+	}
+
+	if title := registeredTitle(metadata); title != "" && parsed.Title.Confidence >= metadataConfidence && !sameTitle(parsed.Title.Value, title) {
+		mismatch("METADATA_TITLE_MISMATCH", parsed.Title.Location, title)
+	}
+	if surname := registeredSurname(metadata); surname != "" && len(parsed.Authors) > 0 {
+		if author := parsed.Authors[0]; author.Confidence >= metadataConfidence && !sameSurname(author.Surname, surname) {
+			mismatch("METADATA_AUTHOR_MISMATCH", author.Location, surname)
+		}
+	}
+	if year := metadata.Issued.Year(); year != 0 && parsed.Year.Confidence >= metadataConfidence {
+		written, err := strconv.Atoi(strings.TrimSpace(parsed.Year.Value))
+		if err == nil && written != year && written != metadata.PublishedPrint.Year() && written != metadata.PublishedOnline.Year() {
+			mismatch("METADATA_YEAR_MISMATCH", parsed.Year.Location, strconv.Itoa(year))
+		}
+	}
+	if metadata.Volume != "" && parsed.Volume.Confidence >= metadataConfidence && !strings.EqualFold(strings.TrimSpace(parsed.Volume.Value), strings.TrimSpace(metadata.Volume)) {
+		mismatch("METADATA_VOLUME_MISMATCH", parsed.Volume.Location, metadata.Volume)
+	}
+	if metadata.Page != "" && parsed.Pages.Confidence >= metadataConfidence && firstPage(parsed.Pages.Value) != firstPage(metadata.Page) {
+		mismatch("METADATA_PAGES_MISMATCH", parsed.Pages.Location, metadata.Page)
+	}
+	return issues
+}
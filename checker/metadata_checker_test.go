@@ -0,0 +1,141 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var lynchMetadata = Metadata{
+	DOI:             "10.1016/0168-583X(91)95671-Y",
+	Title:           []string{"Approximations to multiple Coulomb scattering"},
+	Author:          []MetadataAuthor{{Given: "Gerald R.", Family: "Lynch"}, {Given: "Orin I.", Family: "Dahl"}},
+	Volume:          "58",
+	Page:            "6-10",
+	Issued:          MetadataDate{DateParts: [][]int{{1991, 5}}},
+	PublishedOnline: MetadataDate{DateParts: [][]int{{2002, 10, 24}}},
+}
+
+func TestCheckMetadata(t *testing.T) {
+	tests := []struct {
+		name        string
+		ref         string
+		doi         string
+		issueTypes  []string
+		suggestions []string
+	}{
+		{
+			name: "Matches",
+			ref:  "G. R. Lynch and O. I. Dahl, ``Approximations to multiple Coulomb scattering'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6--10, May 1991.",
+		},
+		{
+			name: "Year published online",
+			ref:  "G. R. Lynch and O. I. Dahl, ``Approximations to multiple Coulomb scattering'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6--10, Oct. 2002.",
+		},
+		{
+			name: "Title with different punctuation",
+			ref:  "G. R. Lynch and O. I. Dahl, ``Approximations to Multiple {C}oulomb Scattering,'' \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, pp. 6, 1991.",
+		},
+		{
+			name:        "Copied from another reference",
+			ref:         "A. Author and O. I. Dahl, ``Beam dynamics of something else'', \\textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 59, no. 1, pp. 12--15, May 1992.",
+			issueTypes:  []string{"METADATA_TITLE_MISMATCH", "METADATA_AUTHOR_MISMATCH", "METADATA_YEAR_MISMATCH", "METADATA_VOLUME_MISMATCH", "METADATA_PAGES_MISMATCH"},
+			suggestions: []string{"Approximations to multiple Coulomb scattering", "Lynch", "1991", "58", "6-10"},
+		},
+		{
+			name: "Accented surname",
+			ref:  "A. M\\\"{u}ller, ``A title'', \\textit{Phys. Rev. Lett.}, vol. 1, p. 1, 2020.",
+			doi:  "10.1103/accented",
+		},
+		{
+			name: "Not registered",
+			ref:  "A. Author, ``A title'', \\textit{Phys. Rev. Lett.}, vol. 1, p. 1, 2020.",
+			doi:  "10.1103/missing",
+		},
+	}
+
+	provider := NewMetadataFixture(lynchMetadata, Metadata{DOI: "10.1103/accented", Title: []string{"A title"}, Author: []MetadataAuthor{{Family: "Müller"}}, Volume: "1", Page: "1"})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doi := tt.doi
+			if doi == "" {
+				doi = lynchMetadata.DOI
+			}
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + " \\url{doi:" + doi + "}\n\\end{thebibliography}\n\\end{document}"
+			bibItem := finder.Finder(structs.Request{Content: contents}).BibItems[0]
+			issues := CheckMetadata(context.Background(), provider, bibItem)
+			if len(issues) != len(tt.issueTypes) {
+				t.Fatalf("CheckMetadata() = %v, want %v", issues, tt.issueTypes)
+			}
+			for i, issue := range issues {
+				if issue.Type != tt.issueTypes[i] || issue.Suggestion != tt.suggestions[i] {
+					t.Errorf("issue %d = %s with suggestion %q, want %s with suggestion %q", i, issue.Type, issue.Suggestion, tt.issueTypes[i], tt.suggestions[i])
+				}
+				if issue.Location.End <= issue.Location.Start {
+					t.Errorf("issue %d has no location", i)
+				}
+			}
+		})
+	}
+}
+
+func TestSameSurname(t *testing.T) {
+	tests := []struct {
+		written    string
+		registered string
+		want       bool
+	}{
+		{`M\"{u}ller`, "Müller", true},
+		{"Muller", "Müller", true},
+		{"Meer", "van der Meer", true},
+		{"Dahl", "Lynch", false},
+	}
+	for _, tt := range tests {
+		if got := sameSurname(tt.written, tt.registered); got != tt.want {
+			t.Errorf("sameSurname(%q, %q) = %v, want %v", tt.written, tt.registered, got, tt.want)
+		}
+	}
+}
+
+func TestCrossrefProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/works/10.1103/PhysRevLett.1.1" {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("User-Agent") != "catscan-latex (mailto:editor@example.com)" {
+			t.Errorf("User-Agent = %q", r.Header.Get("User-Agent"))
+		}
+		fmt.Fprint(w, `{"status":"ok","message":{"DOI":"10.1103/PhysRevLett.1.1","title":["A title"],"author":[{"given":"A.","family":"Author"}],"volume":"1","page":"1","issued":{"date-parts":[[2020,1]]}}}`)
+	}))
+	defer server.Close()
+	provider := NewCrossrefProvider(server.URL, "editor@example.com")
+
+	metadata, err := provider.Metadata(context.Background(), "10.1103/PhysRevLett.1.1")
+	if err != nil || metadata == nil || metadata.Title[0] != "A title" || metadata.Author[0].Family != "Author" || metadata.Issued.Year() != 2020 {
+		t.Fatalf("Metadata() = %+v, %v", metadata, err)
+	}
+	if metadata, err := provider.Metadata(context.Background(), "10.1103/missing"); err != nil || metadata != nil {
+		t.Errorf("Metadata() of an unregistered DOI = %+v, %v, want nil", metadata, err)
+	}
+}
+
+func TestLoadMetadataFixture(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "works.json")
+	if err := os.WriteFile(fileName, []byte(`[{"DOI":"10.1103/PhysRevLett.1.1","title":["A title"],"issued":{"date-parts":[[2020]]}}]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fixture, err := LoadMetadataFixture(fileName)
+	if err != nil {
+		t.Fatalf("LoadMetadataFixture() error = %v", err)
+	}
+	if metadata, _ := fixture.Metadata(context.Background(), "10.1103/physrevlett.1.1"); metadata == nil || metadata.Issued.Year() != 2020 {
+		t.Errorf("Metadata() = %+v, want the work from the file", metadata)
+	}
+}
@@ -0,0 +1,116 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"fmt"
+	"github.com/dlclark/regexp2"
+	"strconv"
+	"strings"
+)
+
+// conferenceRegex finds the acronym and year of a conference in the name of
+// its proceedings, such as IPAC'23, PCaPAC2022 or LINAC 2024.
+var conferenceRegex = regexp2.MustCompile(`(?<![A-Za-z])([A-Za-z]*[A-Z][A-Za-z]*[A-Z])(\s*['’‘´`+"`"+`]\s*|\s+|)(\d{4}|\d{2})(?!\d)`, 0)
+var pageRangeRegex = regexp2.MustCompile(`^[A-Za-z]*\d+[A-Za-z]*\s*(---|-|—)\s*[A-Za-z]*\d+[A-Za-z]*$`, 0)
+
+const jacowDoiPrefix = "10.18429/"
+
+// conference is the acronym and year of a conference, as written in the name
+// of its proceedings.
+type conference struct {
+	acronym  string
+	year     int
+	location structs.Location
+	text     string
+}
+
+// findConference finds the conference in the name of the proceedings of a
+// bibitem. The year is of four digits, so '23 is 2023.
+func findConference(bibItem structs.BibItem) (conference, bool) {
+	journal := bibItem.Parsed.Journal
+	text, ok := originalText(bibItem, journal.Location)
+	if !ok {
+		return conference{}, false
+	}
+	match, err := conferenceRegex.FindStringMatch(text)
+	if err != nil || match == nil {
+		return conference{}, false
+	}
+	year, _ := strconv.Atoi(match.Groups()[3].String())
+	if year < 100 {
+		year += 2000
+		if year > 2070 {
+			year -= 100
+		}
+	}
+	location := structs.RuneLocation(text, match.Index, match.Length)
+	location.File = journal.Location.File
+	location.Start += journal.Location.Start
+	location.End += journal.Location.Start
+	return conference{acronym: match.Groups()[1].String(), year: year, location: location, text: match.String()}, true
+}
+
+func (c conference) String() string {
+	return fmt.Sprintf("%s'%02d", c.acronym, c.year%100)
+}
+
+// Check proceedings are referenced in the JACoW format
+// e.g. in Proc. IPAC'23, Venice, Italy, May 2023, pp. 1234--1237. doi:10.18429/JACoW-IPAC2023-MOPA001
+func checkProceedings(bibItem structs.BibItem) []structs.Issue {
+	reference := bibItem.Parsed
+	doi := strings.TrimRight(reference.Doi.Value, ".")
+	isJacowDoi := strings.HasPrefix(strings.ToLower(doi), jacowDoiPrefix)
+	if bibItem.IsBibTeX() || !reference.Journal.Found() || !(reference.InProceedings || isJacowDoi) {
+		return nil
+	}
+	newIssue := func(issueType string, location structs.Location) structs.Issue {
+		return structs.Issue{Name: bibItem.Name, Type: issueType, Location: location}
+	}
+	var issues []structs.Issue
+
+	conf, hasConference := findConference(bibItem)
+	if hasConference && conf.text != conf.String() {
+		issue := newIssue("PROCEEDINGS_NAME_FORMAT", conf.location)
+		issue.Suggestion = conf.String()
+		issue.Fix = &structs.Edit{Location: conf.location, Replacement: conf.String()}
+		issues = append(issues, issue)
+	}
+
+	if !reference.Month.Found() {
+		issues = append(issues, newIssue("PROCEEDINGS_MISSING_DATE", reference.Journal.Location))
+	} else {
+		between := structs.Location{Start: reference.Journal.Location.End, End: reference.Month.Location.Start}
+		if text, ok := originalText(bibItem, between); ok && strings.Trim(strings.TrimPrefix(strings.TrimSpace(text), "}"), " ,~\n\t") == "" {
+			issues = append(issues, newIssue("PROCEEDINGS_MISSING_LOCATION", reference.Journal.Location))
+		}
+	}
+	if hasConference && reference.Year.Found() && reference.Year.Value != strconv.Itoa(conf.year) {
+		issue := newIssue("PROCEEDINGS_YEAR_MISMATCH", reference.Year.Location)
+		issue.Suggestion = strconv.Itoa(conf.year)
+		issues = append(issues, issue)
+	}
+
+	if text, ok := originalText(bibItem, reference.Pages.Location); ok && reference.Pages.Found() {
+		if match, err := pageRangeRegex.FindStringMatch(text); err == nil && match != nil {
+			dash := structs.RuneLocation(text, match.Groups()[1].Index, match.Groups()[1].Length)
+			dash.File = reference.Pages.Location.File
+			dash.Start += reference.Pages.Location.Start
+			dash.End += reference.Pages.Location.Start
+			issue := newIssue("PAGES_NOT_EN_DASH", reference.Pages.Location)
+			issue.Fix = &structs.Edit{Location: dash, Replacement: "--"}
+			issues = append(issues, issue)
+		}
+	}
+
+	if isJacowDoi && hasConference {
+		// A malformed DOI is reported by the JACOW_DOI rule.
+		if acronym, year, _, ok := parseJacowDOI(doi); ok {
+			if !strings.EqualFold(acronym, conf.acronym) || year != conf.year {
+				issue := newIssue("PROCEEDINGS_DOI_MISMATCH", reference.Doi.Location)
+				issue.Suggestion = fmt.Sprintf("%s%d", acronym, year)
+				issues = append(issues, issue)
+			}
+		}
+	}
+	return issues
+}
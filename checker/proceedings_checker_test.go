@@ -0,0 +1,81 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"reflect"
+	"testing"
+)
+
+func TestCheckProceedings(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected []string
+		fixes    []string
+	}{
+		{
+			name:     "JACoW format",
+			ref:      `A. Author, "A title", in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1234--1237. \url{doi:10.18429/JACoW-IPAC2023-MOPA001}`,
+			expected: []string{},
+		},
+		{
+			name:     "Four digit year and hyphen",
+			ref:      `A. Author, "A title", in \emph{Proc. IPAC 2023}, Venice, Italy, May 2023, pp. 1234-1237.`,
+			expected: []string{"PROCEEDINGS_NAME_FORMAT", "PAGES_NOT_EN_DASH"},
+			fixes:    []string{"IPAC'23", "--"},
+		},
+		{
+			name:     "Missing location and date",
+			ref:      `A. Author, "A title", in \emph{Proc. LINAC'22}, pp. 1--4.`,
+			expected: []string{"PROCEEDINGS_MISSING_DATE"},
+		},
+		{
+			name:     "Missing location",
+			ref:      `A. Author, "A title", in \emph{Proc. LINAC'22}, Aug. 2022, pp. 1--4.`,
+			expected: []string{"PROCEEDINGS_MISSING_LOCATION"},
+		},
+		{
+			name:     "Year and DOI contradict the conference",
+			ref:      `A. Author, "A title", in \emph{Proc. IPAC'23}, Venice, Italy, May 2022, pp. 1--4. \url{doi:10.18429/JACoW-IPAC2022-MOPA001}`,
+			expected: []string{"PROCEEDINGS_YEAR_MISMATCH", "PROCEEDINGS_DOI_MISMATCH"},
+		},
+		{
+			name:     "Two digit year in the DOI contradicts the conference",
+			ref:      `A. Author, "A title", in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1--4. \url{doi:10.18429/JACoW-IPAC22-MOPA001}`,
+			expected: []string{"PROCEEDINGS_DOI_MISMATCH"},
+		},
+		{
+			name:     "Malformed JACoW DOI is left to JACOW_DOI",
+			ref:      `A. Author, "A title", in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1--4. \url{doi:10.18429/JACoW-IPAC-MOPA001}`,
+			expected: []string{},
+		},
+		{
+			name:     "Journal article",
+			ref:      `A. Author, "A title", \emph{Phys. Rev. Lett.}, vol. 1, pp. 1-4, 2020.`,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\n" + tt.ref + "\n\\end{thebibliography}\n\\end{document}"
+			issues := checkProceedings(finder.Finder(structs.Request{Content: contents}).BibItems[0])
+
+			got := make([]string, 0)
+			var fixes []string
+			for _, issue := range issues {
+				got = append(got, issue.Type)
+				if issue.Fix != nil {
+					fixes = append(fixes, issue.Fix.Replacement)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Fatalf("checkProceedings() = %v, want %v", got, tt.expected)
+			}
+			if !reflect.DeepEqual(fixes, tt.fixes) {
+				t.Errorf("fixes = %q, want %q", fixes, tt.fixes)
+			}
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ProfileValues are conference specific values used by rules and when
+// describing issues.
+type ProfileValues struct {
+	// ExampleDOI is shown to authors as an example of a correctly formatted DOI.
+	ExampleDOI string `json:"exampleDoi" yaml:"exampleDoi"`
+}
+
+// Profile adjusts the default registry for a conference. Rules are enabled or
+// disabled by ID, or by the types of the issues they raise to keep or drop a
+// single issue type of a rule, and severities can be overridden by rule ID or
+// issue type.
+type Profile struct {
+	Name       string                      `json:"name" yaml:"name"`
+	EnableAll  bool                        `json:"enableAll" yaml:"enableAll"`
+	Enable     []string                    `json:"enable" yaml:"enable"`
+	Disable    []string                    `json:"disable" yaml:"disable"`
+	Severities map[string]structs.Severity `json:"severities" yaml:"severities"`
+	Values     ProfileValues               `json:"values" yaml:"values"`
+}
+
+const DefaultProfileName = "default"
+
+var defaultValues = ProfileValues{
+	ExampleDOI: "10.18429/JACoW-IPAC2023-XXXX",
+}
+
+var profilesMu sync.RWMutex
+var profiles = map[string]Profile{
+	DefaultProfileName: {
+		Name:   DefaultProfileName,
+		Values: defaultValues,
+	},
+	"ipac25": {
+		Name:   "ipac25",
+		Values: ProfileValues{ExampleDOI: "10.18429/JACoW-IPAC2025-XXXX"},
+	},
+	"linac26": {
+		Name:   "linac26",
+		Values: ProfileValues{ExampleDOI: "10.18429/JACoW-LINAC2026-XXXX"},
+	},
+	"strict": {
+		Name:      "strict",
+		EnableAll: true,
+		Values:    defaultValues,
+	},
+}
+
+// LookupProfile finds a profile by name. An empty name is the default profile.
+func LookupProfile(name string) (Profile, bool) {
+	if name == "" {
+		name = DefaultProfileName
+	}
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	profile, ok := profiles[strings.ToLower(name)]
+	return profile, ok
+}
+
+func ProfileNames() []string {
+	profilesMu.RLock()
+	defer profilesMu.RUnlock()
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AddProfiles registers profiles, replacing any existing profile of the same
+// name. Values left empty are taken from the default profile. Profiles naming
+// a rule or issue type the default registry does not have, or a severity that
+// does not exist, are rejected, so a typo is not silently ignored.
+func AddProfiles(newProfiles []Profile) error {
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	for _, profile := range newProfiles {
+		if profile.Name == "" {
+			return fmt.Errorf("profile is missing a name")
+		}
+		if err := profile.validate(DefaultRegistry); err != nil {
+			return fmt.Errorf("profile %s: %w", profile.Name, err)
+		}
+		profile.Name = strings.ToLower(profile.Name)
+		if profile.Values.ExampleDOI == "" {
+			profile.Values.ExampleDOI = defaultValues.ExampleDOI
+		}
+		profiles[profile.Name] = profile
+	}
+	return nil
+}
+
+// LoadProfiles reads a list of profiles from a YAML (.yaml, .yml) or JSON file
+// and registers them.
+func LoadProfiles(fileName string) error {
+	content, err := os.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	var loaded []Profile
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &loaded)
+	default:
+		err = json.Unmarshal(content, &loaded)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to parse profiles in %s: %w", fileName, err)
+	}
+	return AddProfiles(loaded)
+}
+
+// validate checks the rules and issue types the profile names are in the
+// registry, and its severities exist.
+func (p Profile) validate(registry *Registry) error {
+	known := make(map[string]bool)
+	for _, rule := range registry.Rules() {
+		known[rule.ID()] = true
+		for _, issueType := range rule.IssueTypes() {
+			known[issueType] = true
+		}
+	}
+	for _, names := range [][]string{p.Enable, p.Disable} {
+		for _, name := range names {
+			if !known[name] {
+				return fmt.Errorf("unknown rule or issue type %s", name)
+			}
+		}
+	}
+	for name, severity := range p.Severities {
+		if !known[name] {
+			return fmt.Errorf("unknown rule or issue type %s", name)
+		}
+		switch severity {
+		case structs.SeverityError, structs.SeverityWarning, structs.SeverityInfo:
+		default:
+			return fmt.Errorf("unknown severity %q for %s, expected error, warning or info", severity, name)
+		}
+	}
+	return nil
+}
+
+// isEnabled reports whether a rule runs under the profile. A rule disabled by
+// ID never runs, and one with an issue type listed in Enable runs, so a single
+// issue type of a rule that is disabled by default can be enabled.
+func (p Profile) isEnabled(rule Rule, enabledInRegistry bool) bool {
+	if slices.Contains(p.Disable, rule.ID()) {
+		return false
+	}
+	if slices.Contains(p.Enable, rule.ID()) || enabledInRegistry || p.EnableAll {
+		return true
+	}
+	for _, issueType := range rule.IssueTypes() {
+		if slices.Contains(p.Enable, issueType) {
+			return true
+		}
+	}
+	return false
+}
+
+// reports reports whether an issue raised by an enabled rule is kept. Issue
+// types listed in Disable are dropped, and when the rule only runs because
+// some of its issue types are listed in Enable, only those are kept.
+func (p Profile) reports(rule Rule, issue structs.Issue, enabledInRegistry bool) bool {
+	if slices.Contains(p.Disable, issue.Type) {
+		return false
+	}
+	return slices.Contains(p.Enable, issue.Type) || slices.Contains(p.Enable, rule.ID()) || enabledInRegistry || p.EnableAll
+}
+
+func (p Profile) severity(rule Rule, issue structs.Issue) structs.Severity {
+	if severity, ok := p.Severities[issue.Type]; ok {
+		return severity
+	}
+	if severity, ok := p.Severities[rule.ID()]; ok {
+		return severity
+	}
+	return issue.Severity
+}
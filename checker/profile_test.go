@@ -0,0 +1,171 @@
+package checker
+
+import (
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		fileName string
+		content  string
+		profile  string
+	}{
+		{
+			name:     "YAML profile",
+			fileName: "profiles.yaml",
+			content: `- name: Test-YAML
+  disable: [VOLUME_ISSUE]
+  severities:
+    DOI_IS_URL: warning
+  values:
+    exampleDoi: 10.18429/JACoW-TEST2030-XXXX
+`,
+			profile: "test-yaml",
+		},
+		{
+			name:     "JSON profile",
+			fileName: "profiles.json",
+			content:  `[{"name": "test-json", "disable": ["VOLUME_ISSUE"], "severities": {"DOI_IS_URL": "warning"}, "values": {"exampleDoi": "10.18429/JACoW-TEST2030-XXXX"}}]`,
+			profile:  "test-json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), tt.fileName)
+			if err := os.WriteFile(fileName, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if err := LoadProfiles(fileName); err != nil {
+				t.Fatalf("LoadProfiles() error = %v", err)
+			}
+			profile, ok := LookupProfile(tt.profile)
+			if !ok {
+				t.Fatalf("LookupProfile() did not find the loaded profile")
+			}
+			if profile.Values.ExampleDOI != "10.18429/JACoW-TEST2030-XXXX" {
+				t.Errorf("ExampleDOI = %v", profile.Values.ExampleDOI)
+			}
+
+			registry := NewRegistry(
+				newDetectorRule("DOI_IS_URL", "", structs.SeverityError, detectDoiIsUrl, nil),
+				newDetectorRule("VOLUME_ISSUE", "", structs.SeverityWarning, detectVolumeIssue, nil),
+			)
+			bibItem := structs.BibItem{Ref: "Vol. 1, Issue 2, \\url{https://doi.org/10.1000/182}"}
+			issues := registry.CheckWithProfile(context.Background(), structs.Contents{BibItems: []structs.BibItem{bibItem}}, profile)
+			if len(issues) != 1 || issues[0].Type != "DOI_IS_URL" || issues[0].Severity != structs.SeverityWarning {
+				t.Errorf("CheckWithProfile() = %v, want a single DOI_IS_URL warning", issues)
+			}
+		})
+	}
+}
+
+func TestLoadProfiles_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "Misspelled rule",
+			content: "- name: test-misspelled\n  disable: [VOLUME_ISUE]\n",
+			err:     "unknown rule or issue type VOLUME_ISUE",
+		},
+		{
+			name:    "Misspelled issue type",
+			content: "- name: test-misspelled\n  enable: [PAGES_NOT_EN_DASHES]\n",
+			err:     "unknown rule or issue type PAGES_NOT_EN_DASHES",
+		},
+		{
+			name:    "Unknown severity",
+			content: "- name: test-misspelled\n  severities:\n    DOI_IS_URL: fatal\n",
+			err:     "unknown severity",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileName := filepath.Join(t.TempDir(), "profiles.yaml")
+			if err := os.WriteFile(fileName, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			if err := LoadProfiles(fileName); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("LoadProfiles() error = %v, want %q", err, tt.err)
+			}
+			if _, ok := LookupProfile("test-misspelled"); ok {
+				t.Errorf("LookupProfile() found the invalid profile")
+			}
+		})
+	}
+}
+
+func TestProfile_EnableAll(t *testing.T) {
+	registry := NewRegistry(newDetectorRule("DOI_NOT_WRAPPED", "", structs.SeverityWarning, detectContainsDoiNotWrappedInUrl, nil))
+	_ = registry.Disable("DOI_NOT_WRAPPED")
+	bibItem := structs.BibItem{Ref: "doi:10.18429/JACoW-IPAC2023-TUPM055"}
+	contents := structs.Contents{BibItems: []structs.BibItem{bibItem}}
+
+	if issues := registry.Check(contents); len(issues) != 0 {
+		t.Errorf("Check() = %v, want no issues from a disabled rule", issues)
+	}
+	strict, _ := LookupProfile("strict")
+	if issues := registry.CheckWithProfile(context.Background(), contents, strict); len(issues) != 1 {
+		t.Errorf("CheckWithProfile() = %v, want the disabled rule to run", issues)
+	}
+}
+
+func TestProfile_IssueTypes(t *testing.T) {
+	journal, _ := DefaultRegistry.Rule("JOURNAL_STYLE")
+	proceedings, _ := DefaultRegistry.Rule("PROCEEDINGS_FORMAT")
+	contents := finder.Finder(structs.Request{Content: "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nJ. Smith, \"A title\", The Physical Review Letters, vol. 1, p. 2, 2020.\n\\bibitem{b}\nA. Author, \"A title\", in \\emph{Proc. IPAC 2023}, Venice, Italy, May 2023, pp. 1234-1237.\n\\end{thebibliography}\n\\end{document}"})
+
+	tests := []struct {
+		name     string
+		profile  Profile
+		disabled []string
+		expected []string
+	}{
+		{
+			name:     "All issue types",
+			expected: []string{"JOURNAL_NOT_ITALIC", "JOURNAL_NOT_ABBREVIATED", "PROCEEDINGS_NAME_FORMAT", "PAGES_NOT_EN_DASH"},
+		},
+		{
+			name:     "Issue types disabled",
+			profile:  Profile{Disable: []string{"JOURNAL_NOT_ABBREVIATED", "PAGES_NOT_EN_DASH"}},
+			expected: []string{"JOURNAL_NOT_ITALIC", "PROCEEDINGS_NAME_FORMAT"},
+		},
+		{
+			name:     "Issue type of a disabled rule enabled",
+			profile:  Profile{Enable: []string{"JOURNAL_NOT_ITALIC"}},
+			disabled: []string{"JOURNAL_STYLE"},
+			expected: []string{"JOURNAL_NOT_ITALIC", "PROCEEDINGS_NAME_FORMAT", "PAGES_NOT_EN_DASH"},
+		},
+		{
+			name:     "Rule disabled by ID",
+			profile:  Profile{Enable: []string{"JOURNAL_NOT_ITALIC"}, Disable: []string{"JOURNAL_STYLE"}},
+			expected: []string{"PROCEEDINGS_NAME_FORMAT", "PAGES_NOT_EN_DASH"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(journal, proceedings)
+			for _, id := range tt.disabled {
+				_ = registry.Disable(id)
+			}
+			got := make([]string, 0)
+			for _, issue := range registry.CheckWithProfile(context.Background(), contents, tt.profile) {
+				got = append(got, issue.Type)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("CheckWithProfile() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
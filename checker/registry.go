@@ -0,0 +1,226 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// defaultWorkers is how many bibitems are checked at once. Bibitem rules may
+// look up DOIs, so most of their time is spent waiting on the network.
+const defaultWorkers = 8
+
+// Registry holds the rules that GetIssues runs, in the order they are run.
+// Rules can be enabled and disabled while the server is running. Rules that
+// look up DOIs use the registry's resolver, which is doi.org unless replaced,
+// and rules that compare metadata and search for missing DOIs use its
+// provider and searcher, of which there are none by default.
+type Registry struct {
+	mu       sync.RWMutex
+	rules    []Rule
+	disabled map[string]bool
+	resolver DOIResolver
+	metadata MetadataProvider
+	searcher DOISearcher
+	workers  int
+}
+
+func NewRegistry(rules ...Rule) *Registry {
+	registry := &Registry{
+		disabled: make(map[string]bool),
+		resolver: NewDOIOrgResolver(),
+		workers:  defaultWorkers,
+	}
+	for _, rule := range rules {
+		if err := registry.Register(rule); err != nil {
+			panic(err)
+		}
+	}
+	return registry
+}
+
+func (r *Registry) Register(rule Rule) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.rules {
+		if existing.ID() == rule.ID() {
+			return fmt.Errorf("rule %s is already registered", rule.ID())
+		}
+		for _, issueType := range rule.IssueTypes() {
+			if slices.Contains(existing.IssueTypes(), issueType) {
+				return fmt.Errorf("issue type %s of rule %s is already raised by rule %s", issueType, rule.ID(), existing.ID())
+			}
+		}
+	}
+	r.rules = append(r.rules, rule)
+	return nil
+}
+
+func (r *Registry) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Rule(nil), r.rules...)
+}
+
+func (r *Registry) Rule(id string) (Rule, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if rule.ID() == id {
+			return rule, true
+		}
+	}
+	return nil, false
+}
+
+func (r *Registry) Enable(id string) error {
+	return r.setEnabled(id, true)
+}
+
+func (r *Registry) Disable(id string) error {
+	return r.setEnabled(id, false)
+}
+
+func (r *Registry) setEnabled(id string, enabled bool) error {
+	if _, ok := r.Rule(id); !ok {
+		return fmt.Errorf("unknown rule %s", id)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if enabled {
+		delete(r.disabled, id)
+	} else {
+		r.disabled[id] = true
+	}
+	return nil
+}
+
+func (r *Registry) IsEnabled(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return !r.disabled[id]
+}
+
+// SetDOIResolver replaces the resolver used by rules that look up DOIs.
+func (r *Registry) SetDOIResolver(resolver DOIResolver) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resolver = resolver
+}
+
+// SetMetadataProvider replaces the provider used by rules that compare a
+// bibitem with the metadata registered for its DOI.
+func (r *Registry) SetMetadataProvider(provider MetadataProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metadata = provider
+}
+
+// SetDOISearcher replaces the searcher used by rules that look for the DOIs of
+// bibitems without one.
+func (r *Registry) SetDOISearcher(searcher DOISearcher) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.searcher = searcher
+}
+
+// SetWorkers sets how many bibitems are checked at once, at least one.
+func (r *Registry) SetWorkers(workers int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.workers = max(workers, 1)
+}
+
+func (r *Registry) enabledRules(scope Scope, profile Profile) []Rule {
+	var rules []Rule
+	for _, rule := range r.Rules() {
+		if rule.Scope() == scope && profile.isEnabled(rule, r.IsEnabled(rule.ID())) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// Check runs every enabled rule against the contents using the default profile.
+func (r *Registry) Check(result structs.Contents) []structs.Issue {
+	profile, _ := LookupProfile(DefaultProfileName)
+	return r.CheckWithProfile(context.Background(), result, profile)
+}
+
+// CheckWithProfile runs every rule enabled by the profile against the
+// contents. Bibitem rules are run first, on several bibitems at once, followed
+// by document and citation rules. Issues are in the order of the bibitems and
+// rules, however long each took. Once ctx is done no more bibitems are started.
+func (r *Registry) CheckWithProfile(ctx context.Context, result structs.Contents, profile Profile) []structs.Issue {
+	r.mu.RLock()
+	resolver, metadata, searcher, workers := r.resolver, r.metadata, r.searcher, r.workers
+	r.mu.RUnlock()
+
+	bibItemRules := r.enabledRules(ScopeBibItem, profile)
+	bibItemIssues := make([][]structs.Issue, len(result.BibItems))
+	runPool(ctx, workers, len(result.BibItems), func(i int) {
+		for _, rule := range bibItemRules {
+			target := Target{Context: ctx, Contents: result, BibItem: result.BibItems[i], Values: profile.Values, Resolver: resolver, Metadata: metadata, Searcher: searcher}
+			bibItemIssues[i] = append(bibItemIssues[i], runRule(rule, profile, target, r.IsEnabled(rule.ID()))...)
+		}
+	})
+	issues := make([]structs.Issue, 0)
+	for _, found := range bibItemIssues {
+		issues = append(issues, found...)
+	}
+
+	for _, rule := range r.enabledRules(ScopeDocument, profile) {
+		issues = append(issues, runRule(rule, profile, Target{Context: ctx, Contents: result, Values: profile.Values, Resolver: resolver, Metadata: metadata, Searcher: searcher}, r.IsEnabled(rule.ID()))...)
+	}
+	citationRules := r.enabledRules(ScopeCitation, profile)
+	for _, citation := range result.Citations {
+		for _, rule := range citationRules {
+			issues = append(issues, runRule(rule, profile, Target{Context: ctx, Contents: result, Citation: citation, Values: profile.Values, Resolver: resolver, Metadata: metadata, Searcher: searcher}, r.IsEnabled(rule.ID()))...)
+		}
+	}
+	return issues
+}
+
+// runPool calls work with each index from 0 to n-1, on at most workers
+// goroutines, and returns once they have all finished. Indexes not yet started
+// when ctx is done are skipped.
+func runPool(ctx context.Context, workers int, n int, work func(i int)) {
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(workers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				work(i)
+			}
+		}()
+	}
+	for i := 0; i < n && ctx.Err() == nil; i++ {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
+
+// runRule checks the target with a rule, dropping the issues the profile does
+// not report and setting the severity of the rest.
+func runRule(rule Rule, profile Profile, target Target, enabledInRegistry bool) []structs.Issue {
+	var issues []structs.Issue
+	for _, issue := range rule.Check(target) {
+		if !profile.reports(rule, issue, enabledInRegistry) {
+			continue
+		}
+		if issue.Severity == "" {
+			issue.Severity = rule.Severity()
+		}
+		issue.Severity = profile.severity(rule, issue)
+		issues = append(issues, issue)
+	}
+	return issues
+}
@@ -0,0 +1,135 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"context"
+	"reflect"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRegistry_Check(t *testing.T) {
+	bibItemRule := NewBibItemRule("BIBITEM", "", structs.SeverityWarning, func(bibItem structs.BibItem) []structs.Issue {
+		return []structs.Issue{{Name: bibItem.Name, Type: "BIBITEM"}}
+	})
+	documentRule := NewRule("DOCUMENT", "", structs.SeverityInfo, ScopeDocument, func(target Target) []structs.Issue {
+		return []structs.Issue{{Type: "DOCUMENT", Severity: structs.SeverityError}}
+	})
+	contents := structs.Contents{BibItems: []structs.BibItem{{Name: "a"}, {Name: "b"}}}
+
+	tests := []struct {
+		name       string
+		disabled   []string
+		types      []string
+		severities []structs.Severity
+	}{
+		{
+			name:       "All rules enabled",
+			types:      []string{"BIBITEM", "BIBITEM", "DOCUMENT"},
+			severities: []structs.Severity{structs.SeverityWarning, structs.SeverityWarning, structs.SeverityError},
+		},
+		{
+			name:       "Bibitem rule disabled",
+			disabled:   []string{"BIBITEM"},
+			types:      []string{"DOCUMENT"},
+			severities: []structs.Severity{structs.SeverityError},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := NewRegistry(bibItemRule, documentRule)
+			for _, id := range tt.disabled {
+				if err := registry.Disable(id); err != nil {
+					t.Fatalf("Disable() error = %v", err)
+				}
+			}
+			issues := registry.Check(contents)
+			if len(issues) != len(tt.types) {
+				t.Fatalf("Check() returned %d issues, want %d", len(issues), len(tt.types))
+			}
+			for i, issue := range issues {
+				if issue.Type != tt.types[i] || issue.Severity != tt.severities[i] {
+					t.Errorf("Check()[%d] = %s/%s, want %s/%s", i, issue.Type, issue.Severity, tt.types[i], tt.severities[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_UnknownRule(t *testing.T) {
+	registry := NewRegistry()
+	if err := registry.Disable("MISSING"); err == nil {
+		t.Errorf("Disable() expected an error for an unknown rule")
+	}
+	if err := registry.Register(NewBibItemRule("A", "", structs.SeverityInfo, nil)); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	if err := registry.Register(NewBibItemRule("A", "", structs.SeverityInfo, nil)); err == nil {
+		t.Errorf("Register() expected an error for a duplicate rule")
+	}
+}
+
+func TestRegistry_CheckWithProfileConcurrently(t *testing.T) {
+	var running, mostRunning atomic.Int32
+	slowRule := NewBibItemRule("SLOW", "", structs.SeverityInfo, func(bibItem structs.BibItem) []structs.Issue {
+		now := running.Add(1)
+		defer running.Add(-1)
+		for {
+			most := mostRunning.Load()
+			if now <= most || mostRunning.CompareAndSwap(most, now) {
+				break
+			}
+		}
+		// later bibitems finish first
+		delay, _ := strconv.Atoi(bibItem.Name)
+		time.Sleep(time.Duration(20-delay) * time.Millisecond)
+		return []structs.Issue{{Name: bibItem.Name, Type: "SLOW"}}
+	})
+	var bibItems []structs.BibItem
+	var want []string
+	for i := 0; i < 20; i++ {
+		bibItems = append(bibItems, structs.BibItem{Name: strconv.Itoa(i)})
+		want = append(want, strconv.Itoa(i))
+	}
+	registry := NewRegistry(slowRule)
+	registry.SetWorkers(4)
+	profile, _ := LookupProfile(DefaultProfileName)
+
+	issues := registry.CheckWithProfile(context.Background(), structs.Contents{BibItems: bibItems}, profile)
+	var got []string
+	for _, issue := range issues {
+		got = append(got, issue.Name)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CheckWithProfile() = %v, want the order of the bibitems %v", got, want)
+	}
+	if most := mostRunning.Load(); most > 4 {
+		t.Errorf("%d bibitems were checked at once, want at most 4", most)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if issues := registry.CheckWithProfile(ctx, structs.Contents{BibItems: bibItems}, profile); len(issues) != 0 {
+		t.Errorf("CheckWithProfile() with a cancelled context = %v, want no bibitems checked", issues)
+	}
+}
+
+func TestRegistry_IssueTypes(t *testing.T) {
+	journal, ok := DefaultRegistry.Rule("JOURNAL_STYLE")
+	if !ok || !reflect.DeepEqual(journal.IssueTypes(), []string{"JOURNAL_NOT_ITALIC", "JOURNAL_NOT_ABBREVIATED"}) {
+		t.Errorf("JOURNAL_STYLE IssueTypes() = %v, want the types it raises", journal.IssueTypes())
+	}
+	volume, _ := DefaultRegistry.Rule("VOLUME_ISSUE")
+	if !reflect.DeepEqual(volume.IssueTypes(), []string{"VOLUME_ISSUE"}) {
+		t.Errorf("VOLUME_ISSUE IssueTypes() = %v, want its ID", volume.IssueTypes())
+	}
+
+	registry := NewRegistry(journal)
+	duplicate := emits(NewBibItemRule("ITALICS", "", structs.SeverityWarning, nil), "JOURNAL_NOT_ITALIC")
+	if err := registry.Register(duplicate); err == nil {
+		t.Errorf("Register() of a rule raising JOURNAL_NOT_ITALIC again succeeded, want an error")
+	}
+}
@@ -0,0 +1,96 @@
+package checker
+
+import (
+	"catscan-latex/structs"
+	"context"
+)
+
+type Scope string
+
+const (
+	ScopeBibItem  Scope = "bibitem"
+	ScopeDocument Scope = "document"
+	ScopeCitation Scope = "citation"
+)
+
+// Target is what a rule is run against. BibItem is only set for bibitem
+// scoped rules, and Citation only for citation scoped rules. Resolver, Metadata
+// and Searcher are the registry's DOI resolver, metadata provider and DOI
+// searcher, and Context is cancelled when the check is no longer wanted.
+type Target struct {
+	Context  context.Context
+	Contents structs.Contents
+	BibItem  structs.BibItem
+	Citation structs.Citation
+	Values   ProfileValues
+	Resolver DOIResolver
+	Metadata MetadataProvider
+	Searcher DOISearcher
+}
+
+// Rule is a check run by the registry. IssueTypes are the types of the issues
+// it raises, which are its ID unless it raises several.
+type Rule interface {
+	ID() string
+	Description() string
+	Severity() structs.Severity
+	Scope() Scope
+	IssueTypes() []string
+	Check(target Target) []structs.Issue
+}
+
+type rule struct {
+	id          string
+	description string
+	severity    structs.Severity
+	scope       Scope
+	issueTypes  []string
+	check       func(target Target) []structs.Issue
+}
+
+func (r rule) ID() string                          { return r.id }
+func (r rule) Description() string                 { return r.description }
+func (r rule) Severity() structs.Severity          { return r.severity }
+func (r rule) Scope() Scope                        { return r.scope }
+func (r rule) Check(target Target) []structs.Issue { return r.check(target) }
+
+func (r rule) IssueTypes() []string {
+	if len(r.issueTypes) == 0 {
+		return []string{r.id}
+	}
+	return append([]string(nil), r.issueTypes...)
+}
+
+// emits records the issue types a rule made by NewRule raises, when they are
+// not just its ID.
+func emits(r Rule, issueTypes ...string) Rule {
+	withTypes := r.(rule)
+	withTypes.issueTypes = issueTypes
+	return withTypes
+}
+
+func NewRule(id string, description string, severity structs.Severity, scope Scope, check func(target Target) []structs.Issue) Rule {
+	return rule{id: id, description: description, severity: severity, scope: scope, check: check}
+}
+
+func NewBibItemRule(id string, description string, severity structs.Severity, check func(bibItem structs.BibItem) []structs.Issue) Rule {
+	return NewRule(id, description, severity, ScopeBibItem, func(target Target) []structs.Issue {
+		return check(target.BibItem)
+	})
+}
+
+// newDetectorRule wraps a detect function, which reports at most one location,
+// into a rule that raises an issue of the same type as the rule ID. When fix is
+// not nil, it is used to suggest an edit for the detected location.
+func newDetectorRule(id string, description string, severity structs.Severity, detect func(bibItem structs.BibItem) (bool, *structs.Location), fix func(bibItem structs.BibItem, location structs.Location) *structs.Edit) Rule {
+	return NewBibItemRule(id, description, severity, func(bibItem structs.BibItem) []structs.Issue {
+		if found, location := detect(bibItem); found {
+			issue := structs.Issue{Name: bibItem.Name, Type: id, Location: *location}
+			if fix != nil {
+				issue.Fix = fix(bibItem, *location)
+			}
+			return []structs.Issue{issue}
+		}
+		return nil
+	})
+}
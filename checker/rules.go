@@ -0,0 +1,63 @@
+package checker
+
+import "catscan-latex/structs"
+
+// DefaultRegistry is the registry used by GetIssues.
+var DefaultRegistry = newDefaultRegistry()
+
+// disabledByDefault are rules which are registered, but produce too many
+// false positives, or make too many requests, to be run unless asked for.
+var disabledByDefault = []string{
+	"DOI_NOT_WRAPPED",
+	"METADATA_MISMATCH",
+}
+
+func newDefaultRegistry() *Registry {
+	registry := NewRegistry(
+		newDetectorRule("ET_AL_NOT_WRAPPED", "et al. is not italicised", structs.SeverityWarning, etAlNotItalic, fixEtAlNotItalic),
+		newDetectorRule("ET_AL_WITH_COMMA", "Single author is followed by a comma before et al.", structs.SeverityWarning, detectSingleAuthorCommaBeforeEtAl, fixSingleAuthorCommaBeforeEtAl),
+		NewBibItemRule("AUTHOR_NAME_ORDER", "Author is not written with initials before the surname", structs.SeverityWarning, checkAuthorNameOrder),
+		NewBibItemRule("AUTHOR_LIST_AND", "Last of two or three authors does not follow \"and\"", structs.SeverityWarning, checkAuthorListAnd),
+		NewBibItemRule("AUTHOR_LIST_TOO_LONG", "More than six authors are listed instead of using et al.", structs.SeverityWarning, checkAuthorListTooLong),
+		emits(NewBibItemRule("JOURNAL_STYLE", "Journal or proceedings is not in italics, or journal is not abbreviated", structs.SeverityWarning, checkJournal), "JOURNAL_NOT_ITALIC", "JOURNAL_NOT_ABBREVIATED"),
+		emits(NewBibItemRule("PROCEEDINGS_FORMAT", "Conference proceedings reference is not in the JACoW format", structs.SeverityWarning, checkProceedings), "PROCEEDINGS_NAME_FORMAT", "PROCEEDINGS_MISSING_DATE", "PROCEEDINGS_MISSING_LOCATION", "PROCEEDINGS_YEAR_MISMATCH", "PAGES_NOT_EN_DASH", "PROCEEDINGS_DOI_MISMATCH"),
+		emits(NewBibItemRule("JACOW_DOI", "JACoW DOI names a conference, year or paper ID that does not exist", structs.SeverityError, checkJacowDOI), "JACOW_DOI_FORMAT", "JACOW_DOI_UNKNOWN_CONFERENCE", "JACOW_DOI_UNKNOWN_YEAR", "JACOW_DOI_PAPER_ID"),
+		newDetectorRule("DOI_CONTAINS_SPACE", "DOI has a space after the doi: prefix", structs.SeverityError, detectDoiContainsSpace, fixDoiContainsSpace),
+		newDetectorRule("INCORRECT_STYLE_REFERENCE", "Reference is in a non-JACoW style, such as APS", structs.SeverityWarning, detectReferenceStyleReference, nil),
+		newDetectorRule("DOI_NOT_WRAPPED", "DOI is not wrapped in a \\url{} command", structs.SeverityWarning, detectContainsDoiNotWrappedInUrl, nil),
+		newDetectorRule("NO_DOI_PREFIX", "DOI in \\url{} is missing the doi: prefix", structs.SeverityError, detectNoDoiPrefix, fixNoDoiPrefix),
+		newDetectorRule("DOI_IS_URL", "DOI is written as a https://doi.org/ link", structs.SeverityError, detectDoiIsUrl, fixDoiIsUrl),
+		emits(NewBibItemRule("ARXIV_ID", "arXiv identifier is not valid, or does not follow the scheme for its date", structs.SeverityError, checkArXivID), "ARXIV_ID_FORMAT", "ARXIV_ID_SCHEME"),
+		emits(NewBibItemRule("ISBN", "ISBN does not have 10 or 13 digits, or its check digit is wrong", structs.SeverityError, checkISBN), "ISBN_FORMAT", "ISBN_CHECKSUM"),
+		NewBibItemRule("URL_NOT_WRAPPED", "Link is not wrapped in a \\url{} command", structs.SeverityWarning, checkURLNotWrapped),
+		NewBibItemRule("DOI_URL_NO_SCHEME", "DOI is written as a doi.org link without https://", structs.SeverityError, checkDOIURLNoScheme),
+		newDetectorRule("VOLUME_ISSUE", "Uses Vol. X, Issue X instead of vol. X, no. X", structs.SeverityWarning, detectVolumeIssue, fixVolumeIssue),
+		emits(NewRule("DOI_LOOKUP", "DOI is not registered at doi.org, only resolves once trailing punctuation is removed, or could not be checked", structs.SeverityError, ScopeBibItem, func(target Target) []structs.Issue {
+			if issue := CheckDOIExists(target.Context, target.Resolver, target.BibItem); issue != nil {
+				return []structs.Issue{*issue}
+			}
+			return nil
+		}), "DOI_NOT_FOUND", "DOI_UNVERIFIED", "DOI_ENDS_IN_PERIOD", "DOI_ENDS_IN_PARENTHESIS", "DOI_ENDS_IN_PUNCTUATION"),
+		emits(NewRule("METADATA_MISMATCH", "Title, first author, year, volume or pages differ from the metadata registered for the DOI", structs.SeverityWarning, ScopeBibItem, func(target Target) []structs.Issue {
+			return CheckMetadata(target.Context, target.Metadata, target.BibItem)
+		}), "METADATA_TITLE_MISMATCH", "METADATA_AUTHOR_MISMATCH", "METADATA_YEAR_MISMATCH", "METADATA_VOLUME_MISMATCH", "METADATA_PAGES_MISMATCH"),
+		NewRule("MISSING_DOI", "Reference has no DOI, but one was found for its title and authors", structs.SeverityInfo, ScopeBibItem, func(target Target) []structs.Issue {
+			if issue := CheckMissingDOI(target.Context, target.Searcher, target.BibItem); issue != nil {
+				return []structs.Issue{*issue}
+			}
+			return nil
+		}),
+		newDetectorRule("BIBTEX_MISSING_DOI", "Cited .bib article or proceedings entry has no doi field", structs.SeverityInfo, detectBibTeXMissingDoi, nil),
+		newDetectorRule("BIBTEX_DOI_IN_URL", "Cited .bib entry has its DOI in the url field instead of the doi field", structs.SeverityWarning, detectBibTeXDoiInUrl, fixBibTeXDoiInUrl),
+		newDetectorRule("BIBTEX_WRONG_ENTRY_TYPE", "Cited .bib entry for conference proceedings is not an @inproceedings", structs.SeverityWarning, detectBibTeXWrongEntryType, fixBibTeXWrongEntryType),
+		bibItemNotCitedRule,
+		citationNotFoundRule,
+		citationOrderRule,
+		includeNotFoundRule,
+		includeCycleRule,
+	)
+	for _, id := range disabledByDefault {
+		_ = registry.Disable(id)
+	}
+	return registry
+}
@@ -13,7 +13,7 @@ func FindAbstractLocation(contents string, document structs.Document, comments [
 	start := 0
 	foundStart := false
 	for err == nil && match != nil {
-		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
+		location := structs.RuneLocation(contents, match.Index, match.Length)
 		if structs.LocationIn(location, document.Location) && !locationInComments(location, comments) {
 			start = location.Start
 			foundStart = true
@@ -26,7 +26,7 @@ func FindAbstractLocation(contents string, document structs.Document, comments [
 	end := len(contents) - 1
 	foundEnd := false
 	for err == nil && match != nil {
-		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
+		location := structs.RuneLocation(contents, match.Index, match.Length)
 		if structs.LocationIn(location, document.Location) && !locationInComments(location, comments) {
 			end = location.Start
 			foundEnd = true
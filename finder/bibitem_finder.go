@@ -4,44 +4,132 @@ import (
 	"catscan-latex/structs"
 	"github.com/dlclark/regexp2"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
-func removeComments(contents string) string {
-	var commentRegex = regexp2.MustCompile(`%.*?(\n|$)`, regexp2.Multiline)
-	var withoutComments, _ = commentRegex.Replace(contents, "", 0, -1)
-	return withoutComments
+// normaliseRef removes comments and collapses whitespace in the text of a
+// bibitem, which starts at offset in the contents. Alongside the normalised
+// text it returns the offset in the contents each of its bytes came from.
+func normaliseRef(text string, offset int) (string, []int) {
+	return normaliseText(text, offset, true)
 }
 
-func removeExcessWhitespace(contents string) string {
-	var whitespaceRegex = regexp2.MustCompile(`\s+`, regexp2.Singleline)
-	var withoutMoreThanOneSpace, _ = whitespaceRegex.Replace(contents, " ", 0, -1)
-	return strings.Trim(withoutMoreThanOneSpace, " \n\t")
-}
+func normaliseText(text string, offset int, stripComments bool) (string, []int) {
+	var comments []structs.Location
+	if stripComments {
+		for _, token := range Tokenize(text) {
+			if token.Type == TokenComment {
+				comments = append(comments, token.Location)
+			}
+		}
+	}
 
-var bibItemRegex = regexp2.MustCompile(`(\\bibitem\{(.*?)})(.*?)(?=(\\bibitem|\\end\{thebibliography}))`, regexp2.Singleline)
+	var ref strings.Builder
+	offsets := make([]int, 0, len(text))
+	pendingSpace := -1
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case len(comments) > 0 && comments[0].Start == i:
+			i = comments[0].End
+			comments = comments[1:]
+			continue
+		case unicode.IsSpace(r):
+			if pendingSpace == -1 {
+				pendingSpace = offset + i
+			}
+		default:
+			if pendingSpace != -1 && ref.Len() > 0 {
+				ref.WriteByte(' ')
+				offsets = append(offsets, pendingSpace)
+			}
+			pendingSpace = -1
+			ref.WriteString(text[i : i+size])
+			for j := 0; j < size; j++ {
+				offsets = append(offsets, offset+i+j)
+			}
+		}
+		i += size
+	}
+	return ref.String(), offsets
+}
 
 func findBibItems(contents string) []structs.BibItem {
+	return findBibItemsInTokens(contents, Tokenize(contents), -1)
+}
+
+// findBibItemsInTokens finds the \bibitem commands in the tokens. Each runs to
+// the next \bibitem or \end{thebibliography}, or to end when it is not -1.
+// Commented out bibitems are found too, by reading the text of comments, so
+// they can be told apart with filterBibItemsInComments.
+func findBibItemsInTokens(contents string, tokens []Token, end int) []structs.BibItem {
 	var items []structs.BibItem
-	match, err := bibItemRegex.FindStringMatch(contents)
-	for err == nil && match != nil {
+	for i, token := range tokens {
+		if token.Type == TokenComment {
+			inner := tokenizeRange(contents, token.Inner.Start, token.Inner.End)
+			items = append(items, findBibItemsInTokens(contents, inner, token.Inner.End)...)
+			continue
+		}
+		if token.Type != TokenControlSequence || token.Name != "bibitem" {
+			continue
+		}
+		name, labelEnd, ok := readBibItemLabel(contents, token.Location.End)
+		if !ok {
+			continue
+		}
+		textEnd := end
+		for _, next := range tokens[i+1:] {
+			if next.Location.Start >= labelEnd && ((next.Type == TokenControlSequence && next.Name == "bibitem") ||
+				(next.Type == TokenEndEnvironment && next.Name == "thebibliography")) {
+				textEnd = next.Location.Start
+				break
+			}
+		}
+		if textEnd == -1 {
+			continue
+		}
+		ref, refOffsets := normaliseRef(contents[labelEnd:textEnd], labelEnd)
 		items = append(items, structs.BibItem{
-			Name:         match.Groups()[2].String(),
-			Ref:          removeExcessWhitespace(removeComments(match.Groups()[3].String())),
-			OriginalText: match.Groups()[3].String(),
-			Location: structs.Location{
-				Start: match.Groups()[3].Index,
-				End:   match.Groups()[3].Index + match.Groups()[3].Length,
-			},
-			LabelLocation: structs.Location{
-				Start: match.Groups()[1].Index,
-				End:   match.Groups()[1].Index + match.Groups()[1].Length,
-			},
+			Name:          name,
+			Ref:           ref,
+			RefOffsets:    refOffsets,
+			OriginalText:  contents[labelEnd:textEnd],
+			Location:      structs.Location{Start: labelEnd, End: textEnd},
+			LabelLocation: structs.Location{Start: token.Location.Start, End: labelEnd},
 		})
-		match, err = bibItemRegex.FindNextMatch(match)
 	}
 	return items
 }
 
+// readBibItemLabel reads the optional [label] and the {key} following a
+// \bibitem, returning the key and where it ends.
+func readBibItemLabel(contents string, start int) (string, int, bool) {
+	pos := skipSpaces(contents, start)
+	if pos < len(contents) && contents[pos] == '[' {
+		end := strings.IndexByte(contents[pos:], ']')
+		if end == -1 {
+			return "", 0, false
+		}
+		pos = skipSpaces(contents, pos+end+1)
+	}
+	if pos == len(contents) || contents[pos] != '{' {
+		return "", 0, false
+	}
+	end := strings.IndexByte(contents[pos:], '}')
+	if end == -1 {
+		return "", 0, false
+	}
+	return strings.TrimSpace(contents[pos+1 : pos+end]), pos + end + 1, true
+}
+
+func skipSpaces(contents string, pos int) int {
+	for pos < len(contents) && isSpace(contents[pos]) {
+		pos++
+	}
+	return pos
+}
+
 func filterBibItemsInComments(references []structs.BibItem, comments []structs.Comment) []structs.BibItem {
 	var filtered []structs.BibItem
 	for _, ref := range references {
@@ -62,28 +150,37 @@ func filterBibItemInDocument(references []structs.BibItem, document structs.Docu
 	return filtered
 }
 
-var doiRegex = regexp2.MustCompile(`10\.\d{4,9}/[-._\\;()/:a-zA-Z0-9]+`, regexp2.Singleline)
+// doiRegex matches a DOI as written, which may include the LaTeX escapes
+// UnescapeDOI removes, such as \_, and percent-encoding such as %28. Any other
+// backslash starts a command after the DOI, such as \newblock or \\.
+var doiRegex = regexp2.MustCompile(`10\.\d{4,9}/([-._;()/:a-zA-Z0-9]|\\[_&%#$]|\\textunderscore(?![a-zA-Z])|%[0-9A-Fa-f]{2})+`, regexp2.Singleline)
 
 func findLastDoi(reference string) string {
+	lastDoi, _ := findLastDoiIndex(reference)
+	return lastDoi
+}
+
+// findLastDoiIndex returns the last DOI in the reference, and its byte index.
+func findLastDoiIndex(reference string) (string, int) {
 	var lastDoi string
+	lastIndex := -1
 	match, err := doiRegex.FindStringMatch(reference)
 	for err == nil && match != nil {
 		lastDoi = match.Groups()[0].String()
+		lastIndex = structs.RuneLocation(reference, match.Index, match.Length).Start
 		match, err = doiRegex.FindNextMatch(match)
 	}
-	return lastDoi
+	return lastDoi, lastIndex
 }
 
 func findDois(references []structs.BibItem) []structs.BibItem {
 	var dois []structs.BibItem
 	for _, ref := range references {
-		dois = append(dois, structs.BibItem{
-			Name:         ref.Name,
-			Ref:          ref.Ref,
-			OriginalText: ref.OriginalText,
-			Location:     ref.Location,
-			Doi:          findLastDoi(ref.Ref),
-		})
+		doi, index := findLastDoiIndex(ref.Ref)
+		if doi != "" {
+			setDoi(&ref, doi, ref.RefLocation(index, index+len(doi)))
+		}
+		dois = append(dois, ref)
 	}
 	return dois
 }
@@ -93,5 +190,8 @@ func FindValidBibItems(contents string, comments []structs.Comment, document str
 	noCommented := filterBibItemsInComments(all, comments)
 	inDocument := filterBibItemInDocument(noCommented, document)
 	withDois := findDois(inDocument)
+	for i := range withDois {
+		withDois[i].Parsed = ParseReference(withDois[i])
+	}
 	return withDois
 }
@@ -67,6 +67,26 @@ func Test_findLastDoi(t *testing.T) {
 			input:    "D. P. Aguillard \\textit{et al.}, \"Measurement of the Positive Muon Anomalous Magnetic Moment to 0.20 ppm\", \\textit{Phys. Rev. Lett.}, vol. 131, no. 16, p. 161802, Oct. 2023. doi:10.1103/PhysRevLett.131.161802.",
 			expected: "10.1103/PhysRevLett.131.161802.",
 		},
+		{
+			name:     "DOI followed by a line break",
+			input:    "A. Author, in \\emph{Proc. IPAC'23}, doi:10.18429/JACoW-IPAC2023-MOPA001\\\\ Next line",
+			expected: "10.18429/JACoW-IPAC2023-MOPA001",
+		},
+		{
+			name:     "DOI followed by newblock",
+			input:    "A. Author, \\emph{Phys. Rev. AB}, doi:10.1103/PhysRevAB.1.2\\newblock Next block",
+			expected: "10.1103/PhysRevAB.1.2",
+		},
+		{
+			name:     "DOI followed by url",
+			input:    "A. Author, 10.1103/x\\url{https://example.com}",
+			expected: "10.1103/x",
+		},
+		{
+			name:     "DOI with escaped underscores",
+			input:    "A. Author, doi:10.1002/a\\_b\\_c\\newblock",
+			expected: "10.1002/a\\_b\\_c",
+		},
 		{
 			name:     "No DOI",
 			input:    "This is a reference without any DOI",
@@ -87,3 +107,73 @@ func Test_findLastDoi(t *testing.T) {
 		})
 	}
 }
+
+func Test_normaliseRef(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "Whitespace collapsed and trimmed",
+			input:    "\n\tA. Author,\n\t\"Title\"  ",
+			expected: "A. Author, \"Title\"",
+		},
+		{
+			name:     "Comments removed",
+			input:    "A. Author, % first author\n\"Title\"",
+			expected: "A. Author, \"Title\"",
+		},
+		{
+			name:     "Multibyte characters",
+			input:    " Gaël Le~Bec, pp. 6–10",
+			expected: "Gaël Le~Bec, pp. 6–10",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			offset := 100
+			got, offsets := normaliseRef(tt.input, offset)
+			if got != tt.expected {
+				t.Errorf("normaliseRef() = %q, want %q", got, tt.expected)
+			}
+			if len(offsets) != len(got) {
+				t.Fatalf("normaliseRef() returned %d offsets for %d bytes", len(offsets), len(got))
+			}
+			for i := range got {
+				if original := tt.input[offsets[i]-offset]; original != got[i] && got[i] != ' ' {
+					t.Errorf("normaliseRef() byte %d maps to %q, want %q", i, original, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFindValidBibItems(t *testing.T) {
+	contents := `\begin{document}
+\begin{thebibliography}{9}
+\bibitem{a} A. Author, "50\% efficient", % draft
+2020.
+\iffalse
+\bibitem{b} B. Author, 2021.
+\fi
+\bibitem[C]{c} C. Author, 2022.
+\end{thebibliography}
+\end{document}`
+	comments := FindComments(contents)
+	items := FindValidBibItems(contents, comments, FindDocument(contents, comments))
+
+	expected := map[string]string{
+		"a": `A. Author, "50\% efficient", 2020.`,
+		"c": "C. Author, 2022.",
+	}
+	if len(items) != len(expected) {
+		t.Fatalf("FindValidBibItems() found %d bibitems, want %d", len(items), len(expected))
+	}
+	for _, item := range items {
+		if item.Ref != expected[item.Name] {
+			t.Errorf("Ref of %s = %q, want %q", item.Name, item.Ref, expected[item.Name])
+		}
+	}
+}
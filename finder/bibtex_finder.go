@@ -0,0 +1,257 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"github.com/dlclark/regexp2"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// ParseBibTeX reads the entries of a .bib file. Locations are against the file
+// and have File set, so are not changed by Contents.Resolve. @string, @preamble
+// and @comment entries are skipped.
+func ParseBibTeX(file string, content string) []structs.BibItem {
+	parser := bibParser{file: file, content: content}
+	var items []structs.BibItem
+	for {
+		at := strings.IndexByte(content[parser.pos:], '@')
+		if at == -1 {
+			return items
+		}
+		parser.pos += at
+		if item, ok := parser.parseEntry(); ok {
+			items = append(items, item)
+		}
+	}
+}
+
+type bibParser struct {
+	file    string
+	content string
+	pos     int
+}
+
+func (p *bibParser) location(start int, end int) structs.Location {
+	return structs.Location{File: p.file, Start: start, End: end}
+}
+
+func (p *bibParser) skipSpace() {
+	for p.pos < len(p.content) && unicode.IsSpace(rune(p.content[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *bibParser) peek() byte {
+	if p.pos < len(p.content) {
+		return p.content[p.pos]
+	}
+	return 0
+}
+
+// readIdentifier reads an entry type, key, field name or macro.
+func (p *bibParser) readIdentifier() (string, int) {
+	start := p.pos
+	for p.pos < len(p.content) && !strings.ContainsRune(" \t\r\n{}(),=#\"@%", rune(p.content[p.pos])) {
+		p.pos++
+	}
+	return p.content[start:p.pos], start
+}
+
+// skipBalanced moves past a group starting at the current position, which
+// must be { or (. It returns false when the group is never closed.
+func (p *bibParser) skipBalanced(open byte, closing byte) bool {
+	depth := 0
+	for ; p.pos < len(p.content); p.pos++ {
+		switch p.content[p.pos] {
+		case open:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				p.pos++
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (p *bibParser) parseEntry() (structs.BibItem, bool) {
+	entryStart := p.pos
+	p.pos++ // @
+	entryType, typeStart := p.readIdentifier()
+	p.skipSpace()
+	open := p.peek()
+	if entryType == "" || (open != '{' && open != '(') {
+		return structs.BibItem{}, false
+	}
+	closing := byte('}')
+	if open == '(' {
+		closing = ')'
+	}
+	switch strings.ToLower(entryType) {
+	case "string", "preamble", "comment":
+		p.skipBalanced(open, closing)
+		return structs.BibItem{}, false
+	}
+
+	p.pos++
+	p.skipSpace()
+	key, _ := p.readIdentifier()
+	labelEnd := p.pos
+	item := structs.BibItem{
+		Name:              key,
+		EntryType:         strings.ToLower(entryType),
+		EntryTypeLocation: p.location(typeStart, typeStart+len(entryType)),
+		LabelLocation:     p.location(entryStart, labelEnd),
+	}
+
+	for p.pos < len(p.content) {
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			continue
+		case closing:
+			p.pos++
+			return p.finishEntry(item, entryStart), true
+		case '@', 0:
+			// unterminated entry, keep what was read
+			return p.finishEntry(item, entryStart), true
+		}
+		field, ok := p.parseField()
+		if !ok {
+			return p.finishEntry(item, entryStart), true
+		}
+		item.Fields = append(item.Fields, field)
+	}
+	return p.finishEntry(item, entryStart), true
+}
+
+func (p *bibParser) finishEntry(item structs.BibItem, entryStart int) structs.BibItem {
+	item.Location = p.location(entryStart, p.pos)
+	item.OriginalText = p.content[entryStart:p.pos]
+	item.Ref, item.RefOffsets = normaliseText(item.OriginalText, entryStart, false)
+	if doi, ok := item.Field("doi"); ok {
+		raw := strings.TrimSpace(doi.Value)
+		location := doi.Location
+		if trimmed := strings.TrimLeft(doi.Value, " \t\r\n"); trimmed != doi.Value {
+			location.Start += len(doi.Value) - len(trimmed)
+		}
+		location.End = location.Start + len(raw)
+		setDoi(&item, raw, location)
+	}
+	item.Parsed = ParseReference(item)
+	return item
+}
+
+// parseField reads "name = value", where the value is made of braced or quoted
+// strings, numbers and macros joined by #.
+func (p *bibParser) parseField() (structs.BibField, bool) {
+	name, fieldStart := p.readIdentifier()
+	if name == "" {
+		return structs.BibField{}, false
+	}
+	p.skipSpace()
+	if p.peek() != '=' {
+		return structs.BibField{}, false
+	}
+	p.pos++
+
+	var value strings.Builder
+	valueStart, valueEnd := -1, -1
+	for {
+		p.skipSpace()
+		partStart, partEnd := 0, 0
+		switch p.peek() {
+		case '{':
+			start := p.pos
+			if !p.skipBalanced('{', '}') {
+				return structs.BibField{}, false
+			}
+			partStart, partEnd = start+1, p.pos-1
+		case '"':
+			start := p.pos
+			p.pos++
+			depth := 0
+			for p.pos < len(p.content) && (p.content[p.pos] != '"' || depth > 0) {
+				if p.content[p.pos] == '{' {
+					depth++
+				} else if p.content[p.pos] == '}' {
+					depth--
+				}
+				p.pos++
+			}
+			if p.pos == len(p.content) {
+				return structs.BibField{}, false
+			}
+			p.pos++
+			partStart, partEnd = start+1, p.pos-1
+		default:
+			_, start := p.readIdentifier()
+			partStart, partEnd = start, p.pos
+		}
+		if valueStart == -1 {
+			valueStart = partStart
+		}
+		valueEnd = partEnd
+		value.WriteString(p.content[partStart:partEnd])
+
+		p.skipSpace()
+		if p.peek() != '#' {
+			break
+		}
+		p.pos++
+	}
+
+	return structs.BibField{
+		Name:          strings.ToLower(name),
+		Value:         value.String(),
+		Location:      p.location(valueStart, valueEnd),
+		FieldLocation: p.location(fieldStart, p.pos),
+	}, true
+}
+
+var bibResourceRegex = regexp2.MustCompile(`\\(bibliography|addbibresource)\s*(\[[^\]]*\])?\s*\{([^}]*)\}`, 0)
+
+// findBibFiles finds the .bib files named by \bibliography and \addbibresource
+// commands which are not commented out.
+func findBibFiles(project structs.Project, contents string, comments []structs.Comment) []string {
+	var files []string
+	match, err := bibResourceRegex.FindStringMatch(contents)
+	for err == nil && match != nil {
+		location := structs.RuneLocation(contents, match.Index, match.Length)
+		if !locationInComments(location, comments) {
+			for _, name := range strings.Split(match.Groups()[3].String(), ",") {
+				name = strings.TrimSpace(name)
+				if path.Ext(name) != ".bib" {
+					name += ".bib"
+				}
+				if file := resolveInclude(project, project.Main, name); file != "" {
+					files = append(files, file)
+				}
+			}
+		}
+		match, err = bibResourceRegex.FindNextMatch(match)
+	}
+	return files
+}
+
+// findCitedBibTeXItems parses the .bib files used by the project, returning
+// the entries which are cited.
+func findCitedBibTeXItems(project structs.Project, result structs.Contents) []structs.BibItem {
+	cited := make(map[string]bool)
+	for _, citation := range result.Citations {
+		cited[citation.Name] = true
+	}
+	var items []structs.BibItem
+	for _, file := range findBibFiles(project, result.Content, result.Comments) {
+		for _, item := range ParseBibTeX(file, project.Files[file]) {
+			if cited[item.Name] || cited["*"] {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
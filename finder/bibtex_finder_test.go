@@ -0,0 +1,88 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"testing"
+)
+
+func TestParseBibTeX(t *testing.T) {
+	content := `@string{ipac = "Proc. IPAC"}
+@Article{smith2020,
+  author = {J. Smith and {A. N. Other}},
+  title  = "A {Title}",
+  journal = ipac # "'23",
+  year = 2020,
+  doi = { 10.1000/182 }
+}
+@comment{ignored}
+@inproceedings(jones2021, booktitle = {Proc. LINAC'21})
+`
+	items := ParseBibTeX("refs.bib", content)
+	if len(items) != 2 {
+		t.Fatalf("ParseBibTeX() found %d entries, want 2", len(items))
+	}
+
+	smith := items[0]
+	if smith.Name != "smith2020" || smith.EntryType != "article" || !smith.IsBibTeX() {
+		t.Errorf("ParseBibTeX()[0] = %s %s", smith.EntryType, smith.Name)
+	}
+	if got := content[smith.EntryTypeLocation.Start:smith.EntryTypeLocation.End]; got != "Article" {
+		t.Errorf("EntryTypeLocation = %q, want Article", got)
+	}
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"author", "J. Smith and {A. N. Other}"},
+		{"title", "A {Title}"},
+		{"journal", `ipac'23`},
+		{"year", "2020"},
+	}
+	for _, tt := range tests {
+		field, ok := smith.Field(tt.name)
+		if !ok || field.Value != tt.expected {
+			t.Errorf("Field(%q) = %q, want %q", tt.name, field.Value, tt.expected)
+		}
+	}
+	author, _ := smith.Field("author")
+	if got := content[author.FieldLocation.Start:author.FieldLocation.End]; got != "author = {J. Smith and {A. N. Other}}" {
+		t.Errorf("FieldLocation = %q", got)
+	}
+	if smith.Doi != "10.1000/182" || content[smith.DoiLocation.Start:smith.DoiLocation.End] != "10.1000/182" || smith.DoiLocation.File != "refs.bib" {
+		t.Errorf("Doi = %q at %v", smith.Doi, smith.DoiLocation)
+	}
+
+	jones := items[1]
+	if booktitle, ok := jones.Field("booktitle"); jones.Name != "jones2021" || !ok || booktitle.Value != "Proc. LINAC'21" {
+		t.Errorf("ParseBibTeX()[1] = %v", jones)
+	}
+}
+
+func TestFinderProject_BibTeX(t *testing.T) {
+	project := structs.Project{
+		Main: "main.tex",
+		Files: map[string]string{
+			"main.tex": `\documentclass{jacow}
+\begin{document}
+Text \cite{a,b}.
+% \cite{c}
+\bibliography{refs}
+\end{document}
+`,
+			"refs.bib": "@article{a, doi = {10.1000/1}}\n@article{b, doi = {10.1000/2}}\n@article{c, doi = {10.1000/3}}\n",
+		},
+	}
+
+	result, err := FinderProject(project)
+	if err != nil {
+		t.Fatalf("FinderProject() error = %v", err)
+	}
+
+	if len(result.BibItems) != 2 || result.BibItems[0].Name != "a" || result.BibItems[1].Name != "b" {
+		t.Fatalf("FinderProject() found %v, want the cited entries a and b", result.BibItems)
+	}
+	location := result.Resolve(result.BibItems[1].DoiLocation)
+	if location.File != "refs.bib" || project.Files["refs.bib"][location.Start:location.End] != "10.1000/2" {
+		t.Errorf("Resolve() of the DOI = %v", location)
+	}
+}
@@ -2,22 +2,17 @@ package finder
 
 import (
 	"catscan-latex/structs"
-	"github.com/dlclark/regexp2"
 )
 
-var commentRegex = regexp2.MustCompile(`%.*?\n`, regexp2.Singleline)
-
+// FindComments finds the commented out text: % comments, comment environments
+// and \iffalse blocks. An escaped \% does not start a comment, and neither
+// does a % in verbatim text.
 func FindComments(contents string) []structs.Comment {
-	match, err := commentRegex.FindStringMatch(contents)
 	comments := make([]structs.Comment, 0)
-	for err == nil && match != nil {
-		comments = append(comments, structs.Comment{
-			Location: structs.Location{
-				Start: match.Index,
-				End:   match.Index + match.Length,
-			},
-		})
-		match, err = commentRegex.FindNextMatch(match)
+	for _, token := range Tokenize(contents) {
+		if token.Type == TokenComment {
+			comments = append(comments, structs.Comment{Location: token.Location})
+		}
 	}
 	return comments
 }
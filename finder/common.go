@@ -8,11 +8,13 @@ func Finder(in structs.Request) structs.Contents {
 	comments := FindComments(contents)
 	document := FindDocument(contents, comments)
 	bibItems := FindValidBibItems(contents, comments, document)
+	citations := FindCitations(contents, document, comments)
 	return structs.Contents{
-		Document: document,
-		Comments: comments,
-		BibItems: bibItems,
-		Filename: filename,
-		Content:  contents,
+		Document:  document,
+		Comments:  comments,
+		BibItems:  bibItems,
+		Citations: citations,
+		Filename:  filename,
+		Content:   contents,
 	}
 }
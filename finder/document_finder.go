@@ -2,34 +2,30 @@ package finder
 
 import (
 	"catscan-latex/structs"
-	"github.com/dlclark/regexp2"
 )
 
-var documentBeginRegex = regexp2.MustCompile(`\\begin{document}`, 0)
-var documentEndRegex = regexp2.MustCompile(`\\end{document}`, 0)
-
+// FindDocument finds the document environment. Its location runs from the
+// start of \begin{document} to the start of \end{document}, ignoring any
+// which are commented out or in verbatim text.
 func FindDocument(contents string, comments []structs.Comment) structs.Document {
-	match, err := documentBeginRegex.FindStringMatch(contents)
-	start := 0
-	for err == nil && match != nil {
-		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
-		if !locationInComments(location, comments) {
-			start = location.Start
-			break
+	start := -1
+	end := -1
+	for _, token := range Tokenize(contents) {
+		if token.Name != "document" || locationInComments(token.Location, comments) {
+			continue
 		}
-		match, err = documentBeginRegex.FindNextMatch(match)
-	}
-
-	match, err = documentEndRegex.FindStringMatch(contents)
-	end := len(contents) - 1
-	for err == nil && match != nil {
-		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
-		if !locationInComments(location, comments) {
-			end = location.Start
-			break
+		if token.Type == TokenBeginEnvironment && start == -1 {
+			start = token.Location.Start
+		}
+		if token.Type == TokenEndEnvironment && end == -1 {
+			end = token.Location.Start
 		}
-		match, err = documentEndRegex.FindNextMatch(match)
 	}
-
+	if start == -1 {
+		start = 0
+	}
+	if end == -1 {
+		end = len(contents) - 1
+	}
 	return structs.Document{Location: structs.Location{Start: start, End: end}}
 }
@@ -0,0 +1,54 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"net/url"
+	"strings"
+)
+
+// latexEscapes are the escaped characters that may be written in a DOI.
+var latexEscapes = strings.NewReplacer(`\_`, "_", `\&`, "&", `\%`, "%", `\#`, "#", `\$`, "$", `\textunderscore`, "_")
+
+// NormaliseDOI turns a DOI as written in LaTeX into the DOI it names. Trailing
+// punctuation is dropped, a closing parenthesis only when the DOI has no
+// opening one to match it, and the rest is unescaped by UnescapeDOI. It also
+// returns how much of raw the DOI was read from, so the punctuation dropped is
+// raw[length:].
+func NormaliseDOI(raw string) (string, int) {
+	length := len(raw)
+	for length > 0 {
+		last := raw[length-1]
+		if strings.IndexByte(".,;:", last) != -1 ||
+			(last == ')' && strings.Count(raw[:length], "(") < strings.Count(raw[:length], ")")) {
+			length--
+			continue
+		}
+		break
+	}
+	return UnescapeDOI(raw[:length]), length
+}
+
+// UnescapeDOI removes the LaTeX escapes from a DOI, such as \_, and decodes
+// percent-encoding, such as %28. Invalid percent-encoding is left as it is.
+func UnescapeDOI(raw string) string {
+	doi := latexEscapes.Replace(raw)
+	if decoded, err := url.PathUnescape(doi); err == nil {
+		doi = decoded
+	}
+	return doi
+}
+
+// DOIKey is the form DOIs are compared in, as they are case insensitive.
+func DOIKey(doi string) string {
+	return strings.ToLower(doi)
+}
+
+// setDoi records the DOI of a bibitem as written at location, and normalised.
+func setDoi(item *structs.BibItem, raw string, location structs.Location) {
+	doi, length := NormaliseDOI(raw)
+	item.Doi = raw
+	item.DoiLocation = location
+	item.NormalisedDoi = doi
+	item.NormalisedDoiLocation = location
+	item.NormalisedDoiLocation.End -= len(raw) - length
+}
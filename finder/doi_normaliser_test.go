@@ -0,0 +1,58 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"testing"
+)
+
+func TestNormaliseDOI(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		doi      string
+		trailing string
+	}{
+		{name: "Plain", raw: "10.1103/PhysRevLett.1.1", doi: "10.1103/PhysRevLett.1.1"},
+		{name: "Escaped underscore", raw: `10.1000/a\_b`, doi: "10.1000/a_b"},
+		{name: "Percent-encoded", raw: "10.1002/%28SICI%291097", doi: "10.1002/(SICI)1097"},
+		{name: "Trailing period", raw: "10.1103/PhysRevLett.1.1.", doi: "10.1103/PhysRevLett.1.1", trailing: "."},
+		{name: "Trailing punctuation", raw: "10.1103/x);.", doi: "10.1103/x", trailing: ");."},
+		{name: "Balanced parenthesis", raw: "10.1016/0168-9002(94)", doi: "10.1016/0168-9002(94)"},
+		{name: "Unbalanced parenthesis", raw: "10.1016/0168-9002(94))", doi: "10.1016/0168-9002(94)", trailing: ")"},
+		{name: "Invalid percent-encoding", raw: "10.1000/a%zz", doi: "10.1000/a%zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doi, length := NormaliseDOI(tt.raw)
+			if doi != tt.doi || tt.raw[length:] != tt.trailing {
+				t.Errorf("NormaliseDOI() = %q, trailing %q, want %q, trailing %q", doi, tt.raw[length:], tt.doi, tt.trailing)
+			}
+		})
+	}
+}
+
+func TestFinder_NormalisedDoi(t *testing.T) {
+	contents := "\\begin{document}\n\\begin{thebibliography}{9}\n\\bibitem{a}\nA. Author, \\emph{J. Phys.}, 2020. \\url{doi:10.1000/a\\_b.}\n\\end{thebibliography}\n\\end{document}"
+	bibItem := Finder(structs.Request{Content: contents}).BibItems[0]
+	if bibItem.Doi != `10.1000/a\_b.` || bibItem.NormalisedDoi != "10.1000/a_b" {
+		t.Fatalf("Doi = %q, NormalisedDoi = %q", bibItem.Doi, bibItem.NormalisedDoi)
+	}
+	if got := contents[bibItem.DoiLocation.Start:bibItem.DoiLocation.End]; got != bibItem.Doi {
+		t.Errorf("DoiLocation is of %q, want %q", got, bibItem.Doi)
+	}
+	if got := contents[bibItem.NormalisedDoiLocation.Start:bibItem.NormalisedDoiLocation.End]; got != `10.1000/a\_b` {
+		t.Errorf("NormalisedDoiLocation is of %q, want the DOI without the period", got)
+	}
+}
+
+func TestParseBibTeX_NormalisedDoi(t *testing.T) {
+	content := "@article{a,\n  doi = {10.1002/%28SICI%291097-4636.}\n}\n"
+	bibItem := ParseBibTeX("refs.bib", content)[0]
+	if bibItem.NormalisedDoi != "10.1002/(SICI)1097-4636" {
+		t.Errorf("NormalisedDoi = %q", bibItem.NormalisedDoi)
+	}
+	if got := content[bibItem.NormalisedDoiLocation.Start:bibItem.NormalisedDoiLocation.End]; got != "10.1002/%28SICI%291097-4636" {
+		t.Errorf("NormalisedDoiLocation is of %q, want the DOI as written without the period", got)
+	}
+}
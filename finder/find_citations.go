@@ -1,38 +1,99 @@
 package finder
 
-//
-//var abstractBeginRegex = regexp2.MustCompile(`\\begin{abstract}`, 0)
-//var abstractEndRegex = regexp2.MustCompile(`\\end{abstract}`, 0)
-//
-//func FindCitations(contents string, document structs.Document, comments []structs.Comment) []structs.Citation {
-//	match, err := abstractBeginRegex.FindStringMatch(contents)
-//	start := 0
-//	foundStart := false
-//	for err == nil && match != nil {
-//		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
-//		if structs.LocationIn(location, document.Location) && !locationInComments(location, comments) {
-//			start = location.Start
-//			foundStart = true
-//			break
-//		}
-//		match, err = abstractBeginRegex.FindNextMatch(match)
-//	}
-//
-//	match, err = abstractEndRegex.FindStringMatch(contents)
-//	end := len(contents) - 1
-//	foundEnd := false
-//	for err == nil && match != nil {
-//		location := structs.Location{Start: match.Index, End: match.Index + match.Length}
-//		if structs.LocationIn(location, document.Location) && !locationInComments(location, comments) {
-//			end = location.Start
-//			foundEnd = true
-//			break
-//		}
-//		match, err = abstractEndRegex.FindNextMatch(match)
-//	}
-//
-//	if !foundStart || !foundEnd {
-//		return nil
-//	}
-//	return &structs.Location{Start: start, End: end}
-//}
+import (
+	"catscan-latex/structs"
+	"strings"
+)
+
+// citeCommands are the commands which cite keys: \cite and \nocite, those of
+// natbib such as \citep and \citet, and those of biblatex such as \parencite
+// and \textcite. Commands that only look like them, such as \citestyle, are
+// not included.
+var citeCommands = map[string]bool{
+	"cite": true, "Cite": true, "nocite": true,
+	// natbib
+	"citet": true, "Citet": true, "citep": true, "Citep": true,
+	"citealt": true, "Citealt": true, "citealp": true, "Citealp": true,
+	"citeauthor": true, "Citeauthor": true, "citefullauthor": true,
+	"citeyear": true, "citeyearpar": true, "citenum": true,
+	// biblatex
+	"parencite": true, "Parencite": true, "footcite": true, "footcitetext": true,
+	"textcite": true, "Textcite": true, "smartcite": true, "Smartcite": true,
+	"supercite": true, "autocite": true, "Autocite": true, "fullcite": true,
+	"footfullcite": true, "citetitle": true, "citedate": true, "citeurl": true,
+	"notecite": true, "Notecite": true, "pnotecite": true, "Pnotecite": true,
+	"fnotecite": true,
+}
+
+// FindCitations finds the keys cited in the document body, one citation per
+// key, in the order they appear. A \nocite{*} is reported as the key "*".
+func FindCitations(contents string, document structs.Document, comments []structs.Comment) []structs.Citation {
+	citations := make([]structs.Citation, 0)
+	for _, token := range Tokenize(contents) {
+		if token.Type != TokenControlSequence || !citeCommands[token.Name] {
+			continue
+		}
+		if !structs.LocationIn(token.Location, document.Location) || locationInComments(token.Location, comments) {
+			continue
+		}
+		start, end, ok := readCiteKeys(contents, token.Location.End)
+		if !ok {
+			continue
+		}
+		citations = append(citations, splitKeys(contents[start:end], start)...)
+	}
+	return citations
+}
+
+// readCiteKeys reads past the star and up to two optional arguments following
+// a cite command, returning where its {keys} argument starts and ends.
+func readCiteKeys(contents string, pos int) (int, int, bool) {
+	if pos < len(contents) && contents[pos] == '*' {
+		pos++
+	}
+	for i := 0; i < 2; i++ {
+		pos = skipSpaces(contents, pos)
+		if pos == len(contents) || contents[pos] != '[' {
+			break
+		}
+		end := strings.IndexByte(contents[pos:], ']')
+		if end == -1 {
+			return 0, 0, false
+		}
+		pos += end + 1
+	}
+	pos = skipSpaces(contents, pos)
+	if pos == len(contents) || contents[pos] != '{' {
+		return 0, 0, false
+	}
+	end := strings.IndexByte(contents[pos:], '}')
+	if end == -1 {
+		return 0, 0, false
+	}
+	return pos + 1, pos + end, true
+}
+
+// splitKeys splits a comma separated list of keys, which starts at offset in
+// the contents, into citations located at each key.
+func splitKeys(keys string, offset int) []structs.Citation {
+	var citations []structs.Citation
+	start := 0
+	for start <= len(keys) {
+		end := strings.IndexByte(keys[start:], ',')
+		if end == -1 {
+			end = len(keys)
+		} else {
+			end += start
+		}
+		key := strings.TrimSpace(keys[start:end])
+		if key != "" {
+			keyStart := offset + start + strings.Index(keys[start:end], key)
+			citations = append(citations, structs.Citation{
+				Name:     key,
+				Location: structs.Location{Start: keyStart, End: keyStart + len(key)},
+			})
+		}
+		start = end + 1
+	}
+	return citations
+}
@@ -0,0 +1,61 @@
+package finder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindCitations(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected []string
+	}{
+		{
+			name:     "Cite commands",
+			contents: `\begin{document}\cite{a} \citep{b} \citet[p.~2]{c} \cite*{d}\end{document}`,
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "Multiple keys",
+			contents: `\begin{document}\cite{a, b ,c}\end{document}`,
+			expected: []string{"a", "b", "c"},
+		},
+		{
+			name:     "Commented and outside the document",
+			contents: "\\cite{a}\n\\begin{document}\n% \\cite{b}\n\\iffalse\\cite{c}\\fi \\cite{d}\n\\end{document}",
+			expected: []string{"d"},
+		},
+		{
+			name:     "Not a citation",
+			contents: `\begin{document}\citation \verb|\cite{a}|\end{document}`,
+			expected: []string{},
+		},
+		{
+			name:     "biblatex and natbib commands",
+			contents: `\begin{document}\parencite{a} \Textcite{b} \citealp{c} \nocite{d}\end{document}`,
+			expected: []string{"a", "b", "c", "d"},
+		},
+		{
+			name:     "Commands that only look like citations",
+			contents: `\begin{document}\citestyle{plain} \citeauthorstyle{x} \citesetup{y} \excite{z}\end{document}`,
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := FindComments(tt.contents)
+			got := make([]string, 0)
+			for _, citation := range FindCitations(tt.contents, FindDocument(tt.contents, comments), comments) {
+				if key := tt.contents[citation.Location.Start:citation.Location.End]; key != citation.Name {
+					t.Errorf("citation %s is located at %q", citation.Name, key)
+				}
+				got = append(got, citation.Name)
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("FindCitations() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
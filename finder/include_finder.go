@@ -0,0 +1,142 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"errors"
+	"github.com/dlclark/regexp2"
+	"path"
+	"slices"
+	"strings"
+)
+
+var includeRegex = regexp2.MustCompile(`\\(input|include|subfile)\s*\{([^}]*)\}`, 0)
+
+// MaxProjectSize and MaxProjectSegments bound a flattened project, so a file
+// included many times over, directly or through other files, cannot exhaust
+// memory.
+const (
+	MaxProjectSize     = 50 << 20
+	MaxProjectSegments = 100000
+)
+
+// ErrProjectTooLarge is returned when a project flattens to more than
+// MaxProjectSize bytes or MaxProjectSegments segments.
+var ErrProjectTooLarge = errors.New("project is too large once its includes are expanded")
+
+// FinderProject flattens a project, replacing each \input, \include and
+// \subfile with the file it refers to, and runs the finder over the result.
+// Cited entries of the .bib files used are added to the bibitems.
+// Locations found are against the flattened contents, and can be converted
+// back to the file they came from with Contents.Resolve. It returns
+// ErrProjectTooLarge when the includes expand beyond the limits.
+func FinderProject(in structs.Project) (structs.Contents, error) {
+	expander := includeExpander{project: in}
+	expander.expand(in.Main, false, []string{in.Main})
+	if expander.err != nil {
+		return structs.Contents{}, expander.err
+	}
+
+	result := Finder(structs.Request{Content: expander.contents.String(), Filename: in.Main})
+	result.Segments = expander.segments
+	result.Includes = expander.includes
+	result.Files = in.Files
+	result.BibItems = append(result.BibItems, findCitedBibTeXItems(in, result)...)
+	return result, nil
+}
+
+type includeExpander struct {
+	project  structs.Project
+	contents strings.Builder
+	segments []structs.Segment
+	includes []structs.Include
+	err      error
+}
+
+// copy appends the text between start and end of file to the flattened
+// contents, unless that would take the project over the limits.
+func (e *includeExpander) copy(file string, content string, start int, end int) {
+	if start >= end || e.err != nil {
+		return
+	}
+	if e.contents.Len()+end-start > MaxProjectSize || len(e.segments) == MaxProjectSegments {
+		e.err = ErrProjectTooLarge
+		return
+	}
+	e.segments = append(e.segments, structs.Segment{
+		File:   file,
+		Start:  e.contents.Len(),
+		End:    e.contents.Len() + end - start,
+		Offset: start,
+	})
+	e.contents.WriteString(content[start:end])
+}
+
+// expand copies a file into the flattened contents, expanding the files it
+// includes. stack holds the files currently being expanded, to detect cycles.
+// Only the document body of a subfile is copied. Expanding stops once the
+// project is over the limits.
+func (e *includeExpander) expand(file string, isSubfile bool, stack []string) {
+	if e.err != nil {
+		return
+	}
+	content := e.project.Files[file]
+	comments := FindComments(content)
+	start, end := 0, len(content)
+	if isSubfile {
+		document := FindDocument(content, comments)
+		if strings.HasPrefix(content[document.Location.Start:], `\begin{document}`) {
+			start = document.Location.Start + len(`\begin{document}`)
+			end = document.Location.End
+		}
+	}
+
+	match, err := includeRegex.FindStringMatch(content)
+	for err == nil && match != nil && e.err == nil {
+		location := structs.RuneLocation(content, match.Index, match.Length)
+		if location.Start >= start && location.End <= end && !locationInComments(location, comments) {
+			command := match.Groups()[1].String()
+			location.File = file
+			include := structs.Include{
+				Command:  command,
+				Name:     match.Groups()[2].String(),
+				File:     resolveInclude(e.project, file, match.Groups()[2].String()),
+				Location: location,
+			}
+			switch {
+			case include.File == "":
+				include.Error = "file not found"
+			case slices.Contains(stack, include.File):
+				include.Error = "include cycle: " + strings.Join(append(stack, include.File), " -> ")
+			}
+			e.includes = append(e.includes, include)
+			if include.Error == "" {
+				e.copy(file, content, start, location.Start)
+				e.expand(include.File, command == "subfile", append(stack, include.File))
+				start = location.End
+			}
+		}
+		match, err = includeRegex.FindNextMatch(match)
+	}
+	e.copy(file, content, start, end)
+}
+
+// resolveInclude finds the file in the project that an include refers to.
+// LaTeX resolves names relative to the directory of the main file, and adds
+// a .tex extension when the name has none. Subfiles may also be relative to
+// the file including them.
+func resolveInclude(project structs.Project, from string, name string) string {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return ""
+	}
+	directories := []string{path.Dir(project.Main), path.Dir(from)}
+	for _, directory := range directories {
+		candidate := path.Clean(path.Join(directory, name))
+		for _, file := range []string{candidate, candidate + ".tex"} {
+			if _, ok := project.Files[file]; ok {
+				return file
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFinderProject(t *testing.T) {
+	project := structs.Project{
+		Main: "paper/main.tex",
+		Files: map[string]string{
+			"paper/main.tex": `\documentclass{jacow}
+\begin{document}
+\input{sections/intro}
+%\input{sections/old}
+\include{missing}
+\begin{thebibliography}{9}
+\input{refs.tex}
+\end{thebibliography}
+\end{document}
+`,
+			"paper/sections/intro.tex": "Introduction \\input{loop}\n",
+			"paper/sections/loop.tex":  "\\input{sections/intro}",
+			"paper/refs.tex":           "\\bibitem{a}\nA. Author, \\url{doi:10.1000/182}\n",
+		},
+	}
+
+	result, err := FinderProject(project)
+	if err != nil {
+		t.Fatalf("FinderProject() error = %v", err)
+	}
+
+	if len(result.BibItems) != 1 {
+		t.Fatalf("FinderProject() found %d bibitems, want 1", len(result.BibItems))
+	}
+	location := result.Resolve(result.BibItems[0].Location)
+	if location.File != "paper/refs.tex" || project.Files[location.File][location.Start:location.End] != "\nA. Author, \\url{doi:10.1000/182}\n" {
+		t.Errorf("Resolve() = %v, want the bibitem in paper/refs.tex", location)
+	}
+	doiLocation := result.Resolve(result.BibItems[0].DoiLocation)
+	if got := project.Files[doiLocation.File][doiLocation.Start:doiLocation.End]; got != "10.1000/182" {
+		t.Errorf("Resolve() of the DOI = %q", got)
+	}
+
+	expected := []structs.Include{
+		{Command: "input", Name: "sections/intro", File: "paper/sections/intro.tex"},
+		{Command: "input", Name: "loop", File: "paper/sections/loop.tex"},
+		{Command: "input", Name: "sections/intro", File: "paper/sections/intro.tex", Error: "include cycle: paper/main.tex -> paper/sections/intro.tex -> paper/sections/loop.tex -> paper/sections/intro.tex"},
+		{Command: "include", Name: "missing", File: "", Error: "file not found"},
+		{Command: "input", Name: "refs.tex", File: "paper/refs.tex"},
+	}
+	if len(result.Includes) != len(expected) {
+		t.Fatalf("FinderProject() found %d includes, want %d", len(result.Includes), len(expected))
+	}
+	for i, include := range result.Includes {
+		include.Location = structs.Location{}
+		if include != expected[i] {
+			t.Errorf("Includes[%d] = %v, want %v", i, include, expected[i])
+		}
+	}
+	if location := result.Includes[3].Location; project.Files[location.File][location.Start:location.End] != `\include{missing}` {
+		t.Errorf("Includes[3].Location = %v", location)
+	}
+}
+
+func TestFindMainFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected string
+	}{
+		{
+			name: "Only file with a document class",
+			files: map[string]string{
+				"refs.tex":  "\\bibitem{a}",
+				"paper.tex": "\\documentclass[a4paper]{jacow}\n\\begin{document}",
+			},
+			expected: "paper.tex",
+		},
+		{
+			name: "Commented document class is ignored",
+			files: map[string]string{
+				"old.tex":   "%\\documentclass{jacow}\n",
+				"paper.tex": "\\documentclass{jacow}\n",
+			},
+			expected: "paper.tex",
+		},
+		{
+			name: "Subfiles are not the main file",
+			files: map[string]string{
+				"intro.tex":      "\\documentclass[paper.tex]{subfiles}\n",
+				"main/paper.tex": "\\documentclass{jacow}\n",
+			},
+			expected: "main/paper.tex",
+		},
+		{
+			name: "Nearest the root",
+			files: map[string]string{
+				"template/example.tex": "\\documentclass{jacow}\n",
+				"paper.tex":            "\\documentclass{jacow}\n",
+			},
+			expected: "paper.tex",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindMainFile(tt.files)
+			if err != nil || got != tt.expected {
+				t.Errorf("FindMainFile() = %v, %v, want %v", got, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFinderProject_TooLarge(t *testing.T) {
+	// each file includes the next twice, so the project doubles with every file
+	files := map[string]string{"main.tex": "\\input{f1}\\input{f1}"}
+	for i := 1; i < 30; i++ {
+		files[fmt.Sprintf("f%d.tex", i)] = fmt.Sprintf("text \\input{f%d}\\input{f%d}", i+1, i+1)
+	}
+	files["f30.tex"] = "text"
+
+	start := time.Now()
+	if _, err := FinderProject(structs.Project{Main: "main.tex", Files: files}); !errors.Is(err, ErrProjectTooLarge) {
+		t.Errorf("FinderProject() error = %v, want ErrProjectTooLarge", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("FinderProject() took %v, want it to stop at the limit", elapsed)
+	}
+}
@@ -0,0 +1,349 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"strings"
+)
+
+type TokenType int
+
+const (
+	// TokenText is a run of characters with no special meaning to the lexer.
+	TokenText TokenType = iota
+	// TokenControlSequence is a control word such as \bibitem, or a control
+	// symbol such as \%. Name holds the word or symbol without the backslash.
+	TokenControlSequence
+	TokenBeginGroup
+	TokenEndGroup
+	// TokenComment is commented out text. This is a % comment, including the
+	// newline ending it, a comment environment or an \iffalse block.
+	TokenComment
+	// TokenBeginMath and TokenEndMath are the delimiters of inline or display
+	// maths: $, $$, \(, \), \[ and \]. Name holds the delimiter.
+	TokenBeginMath
+	TokenEndMath
+	// TokenBeginEnvironment and TokenEndEnvironment are \begin{name} and
+	// \end{name}, with Name holding the name of the environment.
+	TokenBeginEnvironment
+	TokenEndEnvironment
+	// TokenVerbatim is text LaTeX does not interpret, the body of a verbatim
+	// environment or a \verb command.
+	TokenVerbatim
+)
+
+type Token struct {
+	Type     TokenType
+	Name     string
+	Location structs.Location
+	// Inner is the text commented out by a comment, without the %, \iffalse
+	// or comment environment around it.
+	Inner structs.Location
+}
+
+// verbatimEnvironments hold text which is not interpreted, so may contain
+// unescaped % and \.
+var verbatimEnvironments = map[string]bool{
+	"verbatim":     true,
+	"verbatim*":    true,
+	"Verbatim":     true,
+	"lstlisting":   true,
+	"minted":       true,
+	"filecontents": true,
+}
+
+// Tokenize splits LaTeX source into tokens. Together the tokens cover all of
+// the contents, in order.
+func Tokenize(contents string) []Token {
+	return tokenizeRange(contents, 0, len(contents))
+}
+
+// tokenizeRange tokenizes the contents between start and end, with locations
+// against the whole contents.
+func tokenizeRange(contents string, start int, end int) []Token {
+	l := lexer{contents: contents[:end], pos: start, textStart: -1}
+	for l.pos < len(l.contents) {
+		l.next()
+	}
+	l.flushText(l.pos)
+	return l.tokens
+}
+
+type lexer struct {
+	contents  string
+	pos       int
+	textStart int
+	inMath    string
+	tokens    []Token
+}
+
+func (l *lexer) emit(tokenType TokenType, name string, start int, end int) {
+	l.flushText(start)
+	l.tokens = append(l.tokens, Token{Type: tokenType, Name: name, Location: structs.Location{Start: start, End: end}})
+}
+
+func (l *lexer) emitComment(start int, innerStart int, innerEnd int, end int) {
+	l.flushText(start)
+	l.tokens = append(l.tokens, Token{
+		Type:     TokenComment,
+		Location: structs.Location{Start: start, End: end},
+		Inner:    structs.Location{Start: innerStart, End: innerEnd},
+	})
+}
+
+// flushText emits the text read since the last token, up to end.
+func (l *lexer) flushText(end int) {
+	if l.textStart != -1 && l.textStart < end {
+		l.tokens = append(l.tokens, Token{Type: TokenText, Location: structs.Location{Start: l.textStart, End: end}})
+	}
+	l.textStart = -1
+}
+
+func (l *lexer) next() {
+	start := l.pos
+	switch l.contents[l.pos] {
+	case '%':
+		end := strings.IndexByte(l.contents[start:], '\n')
+		if end == -1 {
+			l.pos = len(l.contents)
+			l.emitComment(start, start+1, l.pos, l.pos)
+		} else {
+			l.pos = start + end + 1
+			l.emitComment(start, start+1, l.pos-1, l.pos)
+		}
+	case '{':
+		l.pos++
+		l.emit(TokenBeginGroup, "{", start, l.pos)
+	case '}':
+		l.pos++
+		l.emit(TokenEndGroup, "}", start, l.pos)
+	case '$':
+		delimiter := "$"
+		if strings.HasPrefix(l.contents[start:], "$$") && l.inMath != "$" {
+			delimiter = "$$"
+		}
+		l.pos += len(delimiter)
+		l.math(delimiter, delimiter, start)
+	case '\\':
+		l.controlSequence()
+	default:
+		if l.textStart == -1 {
+			l.textStart = start
+		}
+		l.pos++
+	}
+}
+
+// math emits a maths delimiter, which closes the maths when it matches the
+// one which opened it.
+func (l *lexer) math(open string, closing string, start int) {
+	delimiter := l.contents[start:l.pos]
+	if l.inMath != "" && delimiter == l.inMath {
+		l.inMath = ""
+		l.emit(TokenEndMath, delimiter, start, l.pos)
+		return
+	}
+	if l.inMath == "" && delimiter == open {
+		l.inMath = closing
+		l.emit(TokenBeginMath, delimiter, start, l.pos)
+		return
+	}
+	if strings.HasPrefix(delimiter, `\`) {
+		l.emit(TokenControlSequence, delimiter[1:], start, l.pos)
+		return
+	}
+	if l.textStart == -1 {
+		l.textStart = start
+	}
+}
+
+func (l *lexer) controlSequence() {
+	start := l.pos
+	l.pos++
+	if l.pos == len(l.contents) {
+		l.emit(TokenControlSequence, "", start, l.pos)
+		return
+	}
+	if !isLetter(l.contents[l.pos]) {
+		l.pos++
+		switch l.contents[l.pos-1] {
+		case '(':
+			l.math(`\(`, `\)`, start)
+		case '[':
+			l.math(`\[`, `\]`, start)
+		case ')', ']':
+			l.math("", "", start)
+		default:
+			l.emit(TokenControlSequence, l.contents[start+1:l.pos], start, l.pos)
+		}
+		return
+	}
+	for l.pos < len(l.contents) && isLetter(l.contents[l.pos]) {
+		l.pos++
+	}
+	name := l.contents[start+1 : l.pos]
+	switch name {
+	case "begin", "end":
+		l.environment(name, start)
+	case "iffalse":
+		l.iffalse(start)
+	case "verb":
+		l.verb(start)
+	default:
+		l.emit(TokenControlSequence, name, start, l.pos)
+	}
+}
+
+// environment reads the name of the environment being started or ended. The
+// bodies of verbatim and comment environments are read up to their \end.
+func (l *lexer) environment(command string, start int) {
+	nameStart := l.pos
+	for nameStart < len(l.contents) && isSpace(l.contents[nameStart]) {
+		nameStart++
+	}
+	if nameStart == len(l.contents) || l.contents[nameStart] != '{' {
+		l.emit(TokenControlSequence, command, start, l.pos)
+		return
+	}
+	nameEnd := strings.IndexByte(l.contents[nameStart:], '}')
+	if nameEnd == -1 {
+		l.emit(TokenControlSequence, command, start, l.pos)
+		return
+	}
+	name := strings.TrimSpace(l.contents[nameStart+1 : nameStart+nameEnd])
+	l.pos = nameStart + nameEnd + 1
+	if command == "end" {
+		l.emit(TokenEndEnvironment, name, start, l.pos)
+		return
+	}
+
+	if name != "comment" && !verbatimEnvironments[name] {
+		l.emit(TokenBeginEnvironment, name, start, l.pos)
+		return
+	}
+	bodyStart := l.pos
+	bodyEnd := len(l.contents)
+	end := bodyEnd
+	endTag := `\end{` + name + `}`
+	if index := strings.Index(l.contents[bodyStart:], endTag); index != -1 {
+		bodyEnd = bodyStart + index
+		end = bodyEnd + len(endTag)
+	}
+	if name == "comment" {
+		l.pos = end
+		l.emitComment(start, bodyStart, bodyEnd, end)
+		return
+	}
+	l.emit(TokenBeginEnvironment, name, start, bodyStart)
+	if bodyStart < bodyEnd {
+		l.emit(TokenVerbatim, name, bodyStart, bodyEnd)
+	}
+	l.pos = end
+	if bodyEnd < end {
+		l.emit(TokenEndEnvironment, name, bodyEnd, end)
+	}
+}
+
+// conditionals are the TeX, e-TeX and pdfTeX conditionals, which are ended by
+// \fi. Commands such as \iff or \ifthenelse start with "if" but are not.
+var conditionals = map[string]bool{
+	"if": true, "ifcat": true, "ifnum": true, "ifdim": true, "ifodd": true,
+	"ifvmode": true, "ifhmode": true, "ifmmode": true, "ifinner": true,
+	"ifvoid": true, "ifhbox": true, "ifvbox": true, "ifx": true, "ifeof": true,
+	"iftrue": true, "iffalse": true, "ifcase": true, "ifdefined": true,
+	"ifcsname": true, "iffontchar": true, "ifincsname": true,
+	"ifpdfprimitive": true, "ifpdfabsnum": true, "ifpdfabsdim": true,
+}
+
+// newifConditionals finds the conditionals defined with \newif, such as
+// \ifdraft from \newif\ifdraft.
+func newifConditionals(contents string) map[string]bool {
+	defined := make(map[string]bool)
+	for rest := contents; ; {
+		index := strings.Index(rest, `\newif`)
+		if index == -1 {
+			return defined
+		}
+		rest = strings.TrimLeft(rest[index+len(`\newif`):], " \t\r\n")
+		if !strings.HasPrefix(rest, `\if`) {
+			continue
+		}
+		end := 1
+		for end < len(rest) && isLetter(rest[end]) {
+			end++
+		}
+		defined[rest[1:end]] = true
+	}
+}
+
+// iffalse reads an \iffalse block up to its \fi, or its \else whose branch is
+// not commented out. Conditionals nested in the block, including those defined
+// with \newif, are skipped.
+func (l *lexer) iffalse(start int) {
+	defined := newifConditionals(l.contents)
+	bodyStart := l.pos
+	depth := 1
+	for l.pos < len(l.contents) {
+		switch l.contents[l.pos] {
+		case '%':
+			if end := strings.IndexByte(l.contents[l.pos:], '\n'); end != -1 {
+				l.pos += end + 1
+			} else {
+				l.pos = len(l.contents)
+			}
+			continue
+		case '\\':
+		default:
+			l.pos++
+			continue
+		}
+		commandStart := l.pos
+		l.pos++
+		for l.pos < len(l.contents) && isLetter(l.contents[l.pos]) {
+			l.pos++
+		}
+		if l.pos == commandStart+1 && l.pos < len(l.contents) {
+			// a control symbol such as \%
+			l.pos++
+			continue
+		}
+		name := l.contents[commandStart+1 : l.pos]
+		switch {
+		case conditionals[name] || defined[name]:
+			depth++
+		case name == "fi" || (name == "else" && depth == 1):
+			depth--
+			if name == "else" || depth == 0 {
+				l.emitComment(start, bodyStart, commandStart, l.pos)
+				return
+			}
+		}
+	}
+	l.emitComment(start, bodyStart, l.pos, l.pos)
+}
+
+// verb reads \verb or \verb* and the text between the delimiters after it.
+func (l *lexer) verb(start int) {
+	if l.pos < len(l.contents) && l.contents[l.pos] == '*' {
+		l.pos++
+	}
+	if l.pos == len(l.contents) || isLetter(l.contents[l.pos]) || isSpace(l.contents[l.pos]) {
+		l.emit(TokenControlSequence, "verb", start, l.pos)
+		return
+	}
+	delimiter := l.contents[l.pos]
+	end := strings.IndexByte(l.contents[l.pos+1:], delimiter)
+	if end == -1 {
+		l.pos = len(l.contents)
+	} else {
+		l.pos += end + 2
+	}
+	l.emit(TokenVerbatim, "verb", start, l.pos)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
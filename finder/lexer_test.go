@@ -0,0 +1,175 @@
+package finder
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	type token struct {
+		Type TokenType
+		Text string
+	}
+	tests := []struct {
+		name     string
+		contents string
+		expected []token
+	}{
+		{
+			name:     "Control sequences and groups",
+			contents: `\emph{et al.}\\`,
+			expected: []token{
+				{TokenControlSequence, `\emph`},
+				{TokenBeginGroup, "{"},
+				{TokenText, "et al."},
+				{TokenEndGroup, "}"},
+				{TokenControlSequence, `\\`},
+			},
+		},
+		{
+			name:     "Escaped percent is not a comment",
+			contents: "50\\% duty % cycle\nnext",
+			expected: []token{
+				{TokenText, "50"},
+				{TokenControlSequence, `\%`},
+				{TokenText, " duty "},
+				{TokenComment, "% cycle\n"},
+				{TokenText, "next"},
+			},
+		},
+		{
+			name:     "Maths",
+			contents: `$x$ \[y\] $$z$$`,
+			expected: []token{
+				{TokenBeginMath, "$"},
+				{TokenText, "x"},
+				{TokenEndMath, "$"},
+				{TokenText, " "},
+				{TokenBeginMath, `\[`},
+				{TokenText, "y"},
+				{TokenEndMath, `\]`},
+				{TokenText, " "},
+				{TokenBeginMath, "$$"},
+				{TokenText, "z"},
+				{TokenEndMath, "$$"},
+			},
+		},
+		{
+			name:     "Environments",
+			contents: `\begin{itemize}\end {itemize}`,
+			expected: []token{
+				{TokenBeginEnvironment, `\begin{itemize}`},
+				{TokenEndEnvironment, `\end {itemize}`},
+			},
+		},
+		{
+			name:     "Verbatim",
+			contents: "\\begin{verbatim}50% \\end{x}\\end{verbatim} \\verb|%|",
+			expected: []token{
+				{TokenBeginEnvironment, `\begin{verbatim}`},
+				{TokenVerbatim, `50% \end{x}`},
+				{TokenEndEnvironment, `\end{verbatim}`},
+				{TokenText, " "},
+				{TokenVerbatim, `\verb|%|`},
+			},
+		},
+		{
+			name:     "Comment environment",
+			contents: "a\\begin{comment}\n\\bibitem{x}\n\\end{comment}b",
+			expected: []token{
+				{TokenText, "a"},
+				{TokenComment, "\\begin{comment}\n\\bibitem{x}\n\\end{comment}"},
+				{TokenText, "b"},
+			},
+		},
+		{
+			name:     "iffalse with nested conditional",
+			contents: `\iffalse \ifx a b \fi c \fi d`,
+			expected: []token{
+				{TokenComment, `\iffalse \ifx a b \fi c \fi`},
+				{TokenText, " d"},
+			},
+		},
+		{
+			name:     "iffalse with commands that are not conditionals",
+			contents: `\iffalse $a \iff b$ \ifthenelse{x}{y}{z} \fi c`,
+			expected: []token{
+				{TokenComment, `\iffalse $a \iff b$ \ifthenelse{x}{y}{z} \fi`},
+				{TokenText, " c"},
+			},
+		},
+		{
+			name:     "iffalse with a conditional defined by newif",
+			contents: `\newif\ifdraft \iffalse \ifdraft a \fi b \fi c`,
+			expected: []token{
+				{TokenControlSequence, `\newif`},
+				{TokenControlSequence, `\ifdraft`},
+				{TokenText, " "},
+				{TokenComment, `\iffalse \ifdraft a \fi b \fi`},
+				{TokenText, " c"},
+			},
+		},
+		{
+			name:     "iffalse with else",
+			contents: `\iffalse a \else b\fi`,
+			expected: []token{
+				{TokenComment, `\iffalse a \else`},
+				{TokenText, " b"},
+				{TokenControlSequence, `\fi`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []token
+			for _, tok := range Tokenize(tt.contents) {
+				got = append(got, token{tok.Type, tt.contents[tok.Location.Start:tok.Location.End]})
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Tokenize() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindComments(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		expected []string
+	}{
+		{
+			name:     "Escaped percent",
+			contents: "50\\% duty cycle % comment\n",
+			expected: []string{"% comment\n"},
+		},
+		{
+			name:     "Comment at the end of the file",
+			contents: "text % comment",
+			expected: []string{"% comment"},
+		},
+		{
+			name:     "Percent in lstlisting",
+			contents: "\\begin{lstlisting}\nx = 5 % 2\n\\end{lstlisting}\n",
+			expected: []string{},
+		},
+		{
+			name:     "iffalse block",
+			contents: "\\iffalse\n\\bibitem{a}\n\\fi\n",
+			expected: []string{"\\iffalse\n\\bibitem{a}\n\\fi"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]string, 0)
+			for _, comment := range FindComments(tt.contents) {
+				got = append(got, tt.contents[comment.Location.Start:comment.Location.End])
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("FindComments() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
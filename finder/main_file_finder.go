@@ -0,0 +1,66 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"fmt"
+	"github.com/dlclark/regexp2"
+	"path"
+	"sort"
+	"strings"
+)
+
+var documentClassRegex = regexp2.MustCompile(`\\documentclass\s*(\[[^\]]*\])?\s*\{([^}]*)\}`, 0)
+
+// FindMainFile finds the file in a project LaTeX should be run on, being the
+// .tex file with an uncommented \documentclass. Files using the subfiles class
+// are only chosen when there is nothing else, and when there is more than one
+// candidate the file nearest the root of the project is chosen.
+func FindMainFile(files map[string]string) (string, error) {
+	type candidate struct {
+		name      string
+		isSubfile bool
+		depth     int
+	}
+	var candidates []candidate
+	for name, content := range files {
+		if strings.ToLower(path.Ext(name)) != ".tex" {
+			continue
+		}
+		class, found := findDocumentClass(content)
+		if !found {
+			continue
+		}
+		candidates = append(candidates, candidate{
+			name:      name,
+			isSubfile: class == "subfiles",
+			depth:     strings.Count(name, "/"),
+		})
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("no .tex file with a \\documentclass was found")
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].isSubfile != candidates[j].isSubfile {
+			return !candidates[i].isSubfile
+		}
+		if candidates[i].depth != candidates[j].depth {
+			return candidates[i].depth < candidates[j].depth
+		}
+		return candidates[i].name < candidates[j].name
+	})
+	return candidates[0].name, nil
+}
+
+func findDocumentClass(content string) (string, bool) {
+	comments := FindComments(content)
+	match, err := documentClassRegex.FindStringMatch(content)
+	for err == nil && match != nil {
+		location := structs.RuneLocation(content, match.Index, match.Length)
+		if !locationInComments(location, comments) {
+			return strings.TrimSpace(match.Groups()[2].String()), true
+		}
+		match, err = documentClassRegex.FindNextMatch(match)
+	}
+	return "", false
+}
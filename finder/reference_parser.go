@@ -0,0 +1,290 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"github.com/dlclark/regexp2"
+	"strings"
+)
+
+var (
+	titleRegex = regexp2.MustCompile("``"+`(.+?)''|"(.+?)"|“(.+?)”|\\textquotedblleft\s*(?:\{(.+?)\}|(.+?))\s*\\textquotedblright`, regexp2.Singleline)
+	etAlRegex  = regexp2.MustCompile(`\\(?:emph|textit)\s*\{\s*et\s+al\.?\s*\}|\{\\(?:em|it)\s+et\s+al\.?\s*\}|\bet\s+al\.?`, 0)
+	// authorSeparatorRegex splits "A. One, B. Two, and C. Three".
+	authorSeparatorRegex = regexp2.MustCompile(`\s*(?:,\s*and\b|,|\band\b)\s*`, 0)
+	authorNameRegex      = regexp2.MustCompile(`^((?:\p{Lu}\p{Ll}?\.(?:\s*-\s*|\s+|(?=\p{Lu}))?)+)\s*(\S.*)$`, 0)
+	initialsRegex        = regexp2.MustCompile(`^(?:\p{Lu}\p{Ll}?\.[\s-]*)+$`, 0)
+	italicRegex          = regexp2.MustCompile(`\\(?:textit|emph)\s*\{((?:[^{}]|\{[^{}]*\})*)\}|\{\\(?:it|em)\s+((?:[^{}]|\{[^{}]*\})*)\}`, 0)
+	proceedingsRegex     = regexp2.MustCompile(`\bin\s+(Proc(?:\.|eedings)[^,]*)`, 0)
+	inRegex              = regexp2.MustCompile(`\bin[\s~]*$`, 0)
+	volumeRegex          = regexp2.MustCompile(`\b[Vv]ol\.?\s*(\d+[A-Za-z]?)`, 0)
+	numberRegex          = regexp2.MustCompile(`\b(?:[Nn]o\.|Issue)\s*(\d+[A-Za-z\-]*)`, 0)
+	pagesRegex           = regexp2.MustCompile(`\bpp?\.[\s~]*([A-Za-z]*\d+[A-Za-z]*(?:\s*(?:---?|-|–|—)\s*[A-Za-z]*\d+[A-Za-z]*)?)`, 0)
+	monthYearRegex       = regexp2.MustCompile(`\b((?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\.?)[\s~]+((?:19|20)\d{2})\b`, 0)
+	yearRegex            = regexp2.MustCompile(`\b((?:19|20)\d{2})\b`, 0)
+	urlRegex             = regexp2.MustCompile(`\\url\s*\{\s*([^}\s]+)\s*\}|(https?://[^\s{}]*[^\s{}.,;)])`, 0)
+	arXivRegex           = regexp2.MustCompile(`(?:arXiv\s*:?\s*|arxiv\.org/(?:abs|pdf)/)(\d{4}\.\d{4,5}(?:v\d+)?|[a-z\-]+(?:\.[A-Z]{2})?/\d{7}(?:v\d+)?)`, regexp2.IgnoreCase)
+)
+
+// ParseReference splits a reference into its fields. A \bibitem is parsed from
+// its text, expecting the JACoW style, so each field has a confidence below 1.
+// A .bib entry already has its fields named, so they are copied across.
+func ParseReference(bibItem structs.BibItem) structs.Reference {
+	if bibItem.IsBibTeX() {
+		return parseBibTeXReference(bibItem)
+	}
+	p := referenceParser{bibItem: bibItem, ref: bibItem.Ref}
+	return p.parse()
+}
+
+// referenceParser finds the fields of a \bibitem. Offsets are of bytes in the
+// Ref of the bibitem, converted with RefLocation once a field is found.
+type referenceParser struct {
+	bibItem structs.BibItem
+	ref     string
+	// used holds the text already taken by a field, so the digits of a DOI
+	// are not mistaken for a year or volume.
+	used []structs.Location
+}
+
+func (p *referenceParser) parse() structs.Reference {
+	var reference structs.Reference
+	if doi, index := findLastDoiIndex(p.ref); doi != "" {
+		reference.Doi = p.field(structs.Location{Start: index, End: index + len(doi)}, 0.9)
+	}
+	if groups := p.match(arXivRegex, 0); groups != nil {
+		reference.ArXiv = p.field(groups[1], 0.9)
+	}
+	if groups := p.match(urlRegex, 0); groups != nil {
+		if groups[1].Start != -1 {
+			reference.Url = p.field(groups[1], 0.9)
+		} else {
+			reference.Url = p.field(groups[2], 0.6)
+		}
+	}
+
+	authorsEnd, journalFrom := -1, 0
+	if groups := p.match(titleRegex, 0); groups != nil {
+		for _, group := range groups[1:] {
+			if group.Start != -1 {
+				reference.Title = p.field(trimLocation(p.ref, group, " ,."), 0.9)
+				break
+			}
+		}
+		authorsEnd, journalFrom = groups[0].Start, groups[0].End
+	}
+	if authorsEnd != -1 {
+		p.parseAuthors(&reference, authorsEnd, 0.8)
+	}
+
+	for _, groups := range p.matches(italicRegex, journalFrom) {
+		name := groups[1]
+		if name.Start == -1 {
+			name = groups[2]
+		}
+		if isEtAl, _ := etAlRegex.MatchString(p.ref[name.Start:name.End]); isEtAl {
+			continue
+		}
+		confidence := 0.8
+		if authorsEnd == -1 {
+			// without a title the authors run up to the journal
+			authorsEnd, confidence = groups[0].Start, 0.6
+			p.parseAuthors(&reference, authorsEnd, 0.5)
+		}
+		reference.Journal = p.field(trimLocation(p.ref, name, " ,"), confidence)
+		inProceedings, _ := inRegex.MatchString(p.ref[journalFrom:groups[0].Start])
+		reference.InProceedings = inProceedings || strings.HasPrefix(reference.Journal.Value, "Proc")
+		break
+	}
+	if !reference.Journal.Found() {
+		if groups := p.match(proceedingsRegex, journalFrom); groups != nil {
+			reference.Journal = p.field(trimLocation(p.ref, groups[1], " ,."), 0.5)
+			reference.InProceedings = true
+		}
+	}
+	if authorsEnd == -1 {
+		// without a title or journal, an et al. still ends the authors
+		if groups := p.match(etAlRegex, 0); groups != nil {
+			authorsEnd = groups[0].End
+			p.parseAuthors(&reference, authorsEnd, 0.5)
+		}
+	}
+
+	if groups := p.match(volumeRegex, journalFrom); groups != nil {
+		reference.Volume = p.field(groups[1], 0.9)
+	}
+	if groups := p.match(numberRegex, journalFrom); groups != nil {
+		reference.Number = p.field(groups[1], 0.9)
+	}
+	if groups := p.match(pagesRegex, journalFrom); groups != nil {
+		reference.Pages = p.field(groups[1], 0.9)
+	}
+	if groups := p.match(monthYearRegex, journalFrom); groups != nil {
+		reference.Month = p.field(groups[1], 0.9)
+		reference.Year = p.field(groups[2], 0.9)
+	} else if all := p.matches(yearRegex, journalFrom); len(all) > 0 {
+		// the year is usually last, after any numbers in the journal name
+		reference.Year = p.field(all[len(all)-1][1], 0.5)
+	}
+	return reference
+}
+
+// parseAuthors splits the text before end into authors, and any et al.
+func (p *referenceParser) parseAuthors(reference *structs.Reference, end int, confidence float64) {
+	list := trimLocation(p.ref, structs.Location{Start: 0, End: end}, " ,")
+	if list.Start == list.End {
+		return
+	}
+	namesEnd := list.End
+	if groups := p.match(etAlRegex, list.Start); groups != nil && groups[0].End <= list.End {
+		reference.EtAl = p.field(groups[0], 0.9)
+		namesEnd = groups[0].Start
+	}
+
+	start := list.Start
+	for start < namesEnd {
+		end, next := namesEnd, namesEnd
+		if groups := p.match(authorSeparatorRegex, start); groups != nil && groups[0].End <= namesEnd {
+			end, next = groups[0].Start, groups[0].End
+		}
+		p.addAuthor(reference, trimLocation(p.ref, structs.Location{Start: start, End: end}, " ,"), confidence)
+		if next == start {
+			break
+		}
+		start = next
+	}
+	reference.AuthorList = p.field(list, confidence)
+}
+
+// addAuthor adds a name such as "J. R. Smith". Names written surname first,
+// "Smith, J. R.", are split by the comma so the initials are joined back to
+// the surname before them.
+func (p *referenceParser) addAuthor(reference *structs.Reference, location structs.Location, confidence float64) {
+	if location.Start == location.End {
+		return
+	}
+	name := p.ref[location.Start:location.End]
+	if isInitials, _ := initialsRegex.MatchString(name); isInitials && len(reference.Authors) > 0 {
+		last := &reference.Authors[len(reference.Authors)-1]
+		if last.Initials == "" {
+			last.Initials = strings.TrimSpace(name)
+			last.SurnameFirst = true
+			last.Location.End = p.bibItem.RefLocation(location.Start, location.End).End
+			last.Confidence = confidence * 0.75
+			return
+		}
+	}
+	author := structs.Author{Surname: name, Location: p.bibItem.RefLocation(location.Start, location.End), Confidence: confidence * 0.5}
+	if match, err := authorNameRegex.FindStringMatch(name); err == nil && match != nil {
+		author.Initials = strings.TrimSpace(match.Groups()[1].String())
+		author.Surname = match.Groups()[2].String()
+		author.Confidence = confidence
+	}
+	reference.Authors = append(reference.Authors, author)
+}
+
+func (p *referenceParser) field(location structs.Location, confidence float64) structs.ReferenceField {
+	p.used = append(p.used, location)
+	return structs.ReferenceField{
+		Value:      p.ref[location.Start:location.End],
+		Location:   p.bibItem.RefLocation(location.Start, location.End),
+		Confidence: confidence,
+	}
+}
+
+// match finds the first match of regex starting at or after from, which does
+// not overlap a field already found. It returns the location of each group,
+// with a Start of -1 for those not taking part in the match.
+func (p *referenceParser) match(regex *regexp2.Regexp, from int) []structs.Location {
+	if all := p.matches(regex, from); len(all) > 0 {
+		return all[0]
+	}
+	return nil
+}
+
+func (p *referenceParser) matches(regex *regexp2.Regexp, from int) [][]structs.Location {
+	var all [][]structs.Location
+	match, err := regex.FindStringMatch(p.ref)
+	for err == nil && match != nil {
+		groups := make([]structs.Location, len(match.Groups()))
+		for i, group := range match.Groups() {
+			groups[i] = structs.Location{Start: -1, End: -1}
+			if len(group.Captures) > 0 {
+				groups[i] = structs.RuneLocation(p.ref, group.Index, group.Length)
+			}
+		}
+		if groups[0].Start >= from && !p.overlapsUsed(groups[0]) {
+			all = append(all, groups)
+		}
+		match, err = regex.FindNextMatch(match)
+	}
+	return all
+}
+
+func (p *referenceParser) overlapsUsed(location structs.Location) bool {
+	for _, used := range p.used {
+		if location.Start < used.End && used.Start < location.End {
+			return true
+		}
+	}
+	return false
+}
+
+// trimLocation moves the ends of a location past any of the characters in cutset.
+func trimLocation(text string, location structs.Location, cutset string) structs.Location {
+	for location.Start < location.End && strings.ContainsRune(cutset, rune(text[location.Start])) {
+		location.Start++
+	}
+	for location.End > location.Start && strings.ContainsRune(cutset, rune(text[location.End-1])) {
+		location.End--
+	}
+	return location
+}
+
+// parseBibTeXReference copies the fields of a .bib entry. The authors are
+// only located to the author field, as its value may be built from strings.
+func parseBibTeXReference(bibItem structs.BibItem) structs.Reference {
+	field := func(names ...string) structs.ReferenceField {
+		for _, name := range names {
+			if value, ok := bibItem.Field(name); ok {
+				return structs.ReferenceField{Value: strings.TrimSpace(value.Value), Location: value.Location, Confidence: 1}
+			}
+		}
+		return structs.ReferenceField{}
+	}
+	reference := structs.Reference{
+		AuthorList: field("author"),
+		Title:      field("title"),
+		Journal:    field("journal", "booktitle"),
+		Volume:     field("volume"),
+		Number:     field("number"),
+		Pages:      field("pages"),
+		Month:      field("month"),
+		Year:       field("year"),
+		Doi:        field("doi"),
+		Url:        field("url"),
+	}
+	_, hasBookTitle := bibItem.Field("booktitle")
+	reference.InProceedings = bibItem.EntryType == "inproceedings" || (hasBookTitle && !field("journal").Found())
+	if eprint := field("eprint"); eprint.Found() && strings.EqualFold(field("archiveprefix").Value, "arxiv") {
+		reference.ArXiv = eprint
+	}
+
+	for _, name := range strings.Split(reference.AuthorList.Value, " and ") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if name == "others" {
+			reference.EtAl = reference.AuthorList
+			continue
+		}
+		author := structs.Author{Surname: name, Location: reference.AuthorList.Location, Confidence: 1}
+		if surname, given, ok := strings.Cut(name, ","); ok {
+			author.Surname, author.Initials = strings.TrimSpace(surname), strings.TrimSpace(given)
+		} else if space := strings.LastIndex(name, " "); space != -1 {
+			author.Initials, author.Surname = name[:space], name[space+1:]
+		}
+		reference.Authors = append(reference.Authors, author)
+	}
+	return reference
+}
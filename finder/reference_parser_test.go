@@ -0,0 +1,133 @@
+package finder
+
+import (
+	"catscan-latex/structs"
+	"testing"
+)
+
+func TestParseReference(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		authors       []string
+		etAl          string
+		title         string
+		journal       string
+		inProceedings bool
+		volume        string
+		number        string
+		pages         string
+		month         string
+		year          string
+		doi           string
+		url           string
+		arXiv         string
+	}{
+		{
+			name:    "Journal article",
+			text:    `G. R. Lynch and O. I. Dahl, \textquotedblleft{Approximations to multiple Coulomb scattering,}\textquotedblright \textit{Nucl. Instrum. Methods Phys. Res., Sect. B}, vol. 58, no. 1, pp. 6–10, May 1991. \url{doi:10.1016/0168-583x(91)95671-y}`,
+			authors: []string{"G. R.|Lynch", "O. I.|Dahl"},
+			title:   "Approximations to multiple Coulomb scattering",
+			journal: "Nucl. Instrum. Methods Phys. Res., Sect. B",
+			volume:  "58",
+			number:  "1",
+			pages:   "6–10",
+			month:   "May",
+			year:    "1991",
+			doi:     "10.1016/0168-583x(91)95671-y",
+		},
+		{
+			name:          "Conference paper",
+			text:          "A. Author, B.-C. Other, and D. Third, ``A title'', in \\textit{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1-4.\n\\url{doi:10.18429/JACoW-IPAC2023-MOPA001}",
+			authors:       []string{"A.|Author", "B.-C.|Other", "D.|Third"},
+			title:         "A title",
+			journal:       "Proc. IPAC'23",
+			inProceedings: true,
+			pages:         "1-4",
+			month:         "May",
+			year:          "2023",
+			doi:           "10.18429/JACoW-IPAC2023-MOPA001",
+		},
+		{
+			name:    "et al. and arXiv",
+			text:    `D. P. Aguillard \textit{et al.}, "Measurement", 2023. arXiv:2308.06230`,
+			authors: []string{"D. P.|Aguillard"},
+			etAl:    `\textit{et al.}`,
+			title:   "Measurement",
+			year:    "2023",
+			arXiv:   "2308.06230",
+		},
+		{
+			name:    "Surname first with a URL",
+			text:    `Smith, J., "A web page", 2019, \url{https://www.jacow.org}`,
+			authors: []string{"J.|Smith"},
+			title:   "A web page",
+			year:    "2019",
+			url:     "https://www.jacow.org",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// offset the text, as the bibitem would be in a file
+			contents := "\\bibitem{a} " + tt.text
+			ref, offsets := normaliseRef(tt.text, 12)
+			reference := ParseReference(structs.BibItem{Ref: ref, RefOffsets: offsets})
+
+			var authors []string
+			for _, author := range reference.Authors {
+				authors = append(authors, author.Initials+"|"+author.Surname)
+			}
+			if len(authors) != len(tt.authors) {
+				t.Errorf("Authors = %q, want %q", authors, tt.authors)
+			} else {
+				for i := range authors {
+					if authors[i] != tt.authors[i] {
+						t.Errorf("Authors[%d] = %q, want %q", i, authors[i], tt.authors[i])
+					}
+				}
+			}
+			if reference.InProceedings != tt.inProceedings {
+				t.Errorf("InProceedings = %v, want %v", reference.InProceedings, tt.inProceedings)
+			}
+
+			fields := []struct {
+				name     string
+				field    structs.ReferenceField
+				expected string
+			}{
+				{"EtAl", reference.EtAl, tt.etAl},
+				{"Title", reference.Title, tt.title},
+				{"Journal", reference.Journal, tt.journal},
+				{"Volume", reference.Volume, tt.volume},
+				{"Number", reference.Number, tt.number},
+				{"Pages", reference.Pages, tt.pages},
+				{"Month", reference.Month, tt.month},
+				{"Year", reference.Year, tt.year},
+				{"Doi", reference.Doi, tt.doi},
+				{"Url", reference.Url, tt.url},
+				{"ArXiv", reference.ArXiv, tt.arXiv},
+			}
+			for _, f := range fields {
+				if f.field.Value != f.expected || f.field.Found() != (f.expected != "") {
+					t.Errorf("%s = %q (confidence %v), want %q", f.name, f.field.Value, f.field.Confidence, f.expected)
+				}
+				if f.field.Found() && contents[f.field.Location.Start:f.field.Location.End] != f.expected {
+					t.Errorf("%s is located at %q", f.name, contents[f.field.Location.Start:f.field.Location.End])
+				}
+			}
+		})
+	}
+}
+
+func TestParseReference_BibTeX(t *testing.T) {
+	content := `@inproceedings{a, author = {Smith, John and A. N. Other and others}, title = {T}, booktitle = {Proc. IPAC'23}, year = 2023}`
+	reference := ParseBibTeX("refs.bib", content)[0].Parsed
+
+	if len(reference.Authors) != 2 || reference.Authors[0].Surname != "Smith" || reference.Authors[0].Initials != "John" || reference.Authors[1].Surname != "Other" {
+		t.Errorf("Authors = %v", reference.Authors)
+	}
+	if !reference.EtAl.Found() || !reference.InProceedings || reference.Journal.Value != "Proc. IPAC'23" || reference.Year.Value != "2023" {
+		t.Errorf("ParseReference() = %+v", reference)
+	}
+}
@@ -0,0 +1,184 @@
+package fixer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around each change.
+const contextLines = 3
+
+type operation struct {
+	kind byte // ' ' unchanged, '-' removed, '+' added
+	line string
+	// aLine and bLine are the 0-based line numbers before and after the change
+	aLine int
+	bLine int
+}
+
+// UnifiedDiff returns the changes from before to after as a unified diff, with
+// both sides labelled with filename so it can be applied with patch -p0. An
+// empty string is returned when there are no changes.
+func UnifiedDiff(filename string, before string, after string) string {
+	if before == after {
+		return ""
+	}
+	operations := diffLines(splitLines(before), splitLines(after))
+
+	// keep every change, and the unchanged lines within contextLines of one
+	include := make([]bool, len(operations))
+	for i, op := range operations {
+		if op.kind == ' ' {
+			continue
+		}
+		for j := max(0, i-contextLines); j <= min(len(operations)-1, i+contextLines); j++ {
+			include[j] = true
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", filename, filename)
+	for i := 0; i < len(operations); {
+		if !include[i] {
+			i++
+			continue
+		}
+		end := i
+		for end < len(operations) && include[end] {
+			end++
+		}
+		writeHunk(&out, operations[i:end])
+		i = end
+	}
+	return out.String()
+}
+
+func writeHunk(out *strings.Builder, operations []operation) {
+	aCount, bCount := 0, 0
+	for _, op := range operations {
+		if op.kind != '+' {
+			aCount++
+		}
+		if op.kind != '-' {
+			bCount++
+		}
+	}
+	// an empty side is numbered from the line before it, otherwise lines are 1-based
+	aStart, bStart := operations[0].aLine, operations[0].bLine
+	if aCount > 0 {
+		aStart++
+	}
+	if bCount > 0 {
+		bStart++
+	}
+	fmt.Fprintf(out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+	for _, op := range operations {
+		out.WriteByte(op.kind)
+		out.WriteString(op.line)
+		if !strings.HasSuffix(op.line, "\n") {
+			out.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
+
+// splitLines splits text into lines, keeping the line endings.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines finds the shortest edit script from a to b using Myers' algorithm,
+// after setting aside the lines common to the start and end of both.
+func diffLines(a []string, b []string) []operation {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	operations := make([]operation, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		operations = append(operations, operation{kind: ' ', line: a[i], aLine: i, bLine: i})
+	}
+	for _, op := range myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]) {
+		op.aLine += prefix
+		op.bLine += prefix
+		operations = append(operations, op)
+	}
+	for i := 0; i < suffix; i++ {
+		aLine, bLine := len(a)-suffix+i, len(b)-suffix+i
+		operations = append(operations, operation{kind: ' ', line: a[aLine], aLine: aLine, bLine: bLine})
+	}
+	return operations
+}
+
+func myers(a []string, b []string) []operation {
+	n, m := len(a), len(b)
+	offset := n + m
+	v := make([]int, 2*offset+2)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	// walk back through the trace, collecting operations in reverse
+	var reversed []operation
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var previousK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			previousK = k + 1
+		} else {
+			previousK = k - 1
+		}
+		previousX := v[offset+previousK]
+		previousY := previousX - previousK
+		for x > previousX && y > previousY {
+			x--
+			y--
+			reversed = append(reversed, operation{kind: ' ', line: a[x], aLine: x, bLine: y})
+		}
+		if d > 0 {
+			if x == previousX {
+				y--
+				reversed = append(reversed, operation{kind: '+', line: b[y], aLine: x, bLine: y})
+			} else {
+				x--
+				reversed = append(reversed, operation{kind: '-', line: a[x], aLine: x, bLine: y})
+			}
+		}
+	}
+
+	operations := make([]operation, len(reversed))
+	for i, op := range reversed {
+		operations[len(reversed)-1-i] = op
+	}
+	return operations
+}
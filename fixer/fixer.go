@@ -0,0 +1,59 @@
+package fixer
+
+import (
+	"catscan-latex/structs"
+	"sort"
+	"strings"
+)
+
+type Result struct {
+	Fixed   string          `json:"fixed"`
+	Diff    string          `json:"diff"`
+	Applied []structs.Issue `json:"applied"`
+	Skipped []structs.Issue `json:"skipped"`
+}
+
+// Fix applies the edits suggested by the issues to the contents of a file.
+// Issues without an edit, or with an edit to another file, are ignored. Edits
+// overlapping an earlier edit, or falling outside the contents, are skipped.
+func Fix(filename string, contents string, issues []structs.Issue) Result {
+	fixable := make([]structs.Issue, 0)
+	for _, issue := range issues {
+		if issue.Fix != nil && (issue.Fix.Location.File == "" || issue.Fix.Location.File == filename) {
+			fixable = append(fixable, issue)
+		}
+	}
+	sort.SliceStable(fixable, func(i, j int) bool {
+		if fixable[i].Fix.Location.Start != fixable[j].Fix.Location.Start {
+			return fixable[i].Fix.Location.Start < fixable[j].Fix.Location.Start
+		}
+		return fixable[i].Fix.Location.End < fixable[j].Fix.Location.End
+	})
+
+	result := Result{Applied: make([]structs.Issue, 0), Skipped: make([]structs.Issue, 0)}
+	var fixed strings.Builder
+	last := 0
+	var previous *structs.Edit
+	for _, issue := range fixable {
+		edit := issue.Fix
+		if previous != nil && *previous == *edit {
+			// the same edit suggested by more than one issue
+			result.Applied = append(result.Applied, issue)
+			continue
+		}
+		if edit.Location.Start < last || edit.Location.End < edit.Location.Start || edit.Location.End > len(contents) {
+			result.Skipped = append(result.Skipped, issue)
+			continue
+		}
+		fixed.WriteString(contents[last:edit.Location.Start])
+		fixed.WriteString(edit.Replacement)
+		last = edit.Location.End
+		previous = edit
+		result.Applied = append(result.Applied, issue)
+	}
+	fixed.WriteString(contents[last:])
+
+	result.Fixed = fixed.String()
+	result.Diff = UnifiedDiff(filename, contents, result.Fixed)
+	return result
+}
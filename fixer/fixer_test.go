@@ -0,0 +1,171 @@
+package fixer
+
+import (
+	"catscan-latex/checker"
+	"catscan-latex/finder"
+	"catscan-latex/structs"
+	"context"
+	"testing"
+)
+
+func TestFix(t *testing.T) {
+	contents := "\\bibitem{a}\nA. Author, \"Title\", vol. 1,\ndoi: 10.1000/182.\n\\bibitem{b}\nB. Author et al.\n"
+	tests := []struct {
+		name    string
+		issues  []structs.Issue
+		fixed   string
+		applied int
+		skipped int
+	}{
+		{
+			name:    "No edits",
+			issues:  []structs.Issue{{Type: "DOI_NOT_FOUND"}},
+			fixed:   contents,
+			applied: 0,
+			skipped: 0,
+		},
+		{
+			name: "Non-overlapping edits",
+			issues: []structs.Issue{
+				{Type: "ET_AL_NOT_WRAPPED", Fix: &structs.Edit{Location: structs.Location{Start: 80, End: 86}, Replacement: "\\emph{et al.}"}},
+				{Type: "DOI_CONTAINS_SPACE", Fix: &structs.Edit{Location: structs.Location{Start: 40, End: 47}, Replacement: "doi:10"}},
+				{Type: "DOI_ENDS_IN_PERIOD", Fix: &structs.Edit{Location: structs.Location{Start: 56, End: 57}}},
+			},
+			fixed:   "\\bibitem{a}\nA. Author, \"Title\", vol. 1,\ndoi:10.1000/182\n\\bibitem{b}\nB. Author \\emph{et al.}\n",
+			applied: 3,
+			skipped: 0,
+		},
+		{
+			name: "Overlapping edit is skipped",
+			issues: []structs.Issue{
+				{Type: "DOI_CONTAINS_SPACE", Fix: &structs.Edit{Location: structs.Location{Start: 40, End: 47}, Replacement: "doi:10"}},
+				{Type: "OTHER", Fix: &structs.Edit{Location: structs.Location{Start: 44, End: 48}, Replacement: ""}},
+			},
+			fixed:   "\\bibitem{a}\nA. Author, \"Title\", vol. 1,\ndoi:10.1000/182.\n\\bibitem{b}\nB. Author et al.\n",
+			applied: 1,
+			skipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Fix("paper.tex", contents, tt.issues)
+			if got.Fixed != tt.fixed {
+				t.Errorf("Fix() fixed = %q, want %q", got.Fixed, tt.fixed)
+			}
+			if len(got.Applied) != tt.applied || len(got.Skipped) != tt.skipped {
+				t.Errorf("Fix() applied %d and skipped %d, want %d and %d", len(got.Applied), len(got.Skipped), tt.applied, tt.skipped)
+			}
+			if (got.Diff == "") != (tt.fixed == contents) {
+				t.Errorf("Fix() diff = %q", got.Diff)
+			}
+		})
+	}
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		before   string
+		after    string
+		expected string
+	}{
+		{
+			name:     "Unchanged",
+			before:   "a\nb\n",
+			after:    "a\nb\n",
+			expected: "",
+		},
+		{
+			name:   "Changes far apart make separate hunks",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:  "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- paper.tex\n+++ paper.tex\n" +
+				"@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		{
+			name:   "Missing newline at end of file",
+			before: "a\nb",
+			after:  "a\nc",
+			expected: "--- paper.tex\n+++ paper.tex\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+c\n\\ No newline at end of file\n",
+		},
+		{
+			name:   "Added lines",
+			before: "a\n",
+			after:  "a\nb\nc\n",
+			expected: "--- paper.tex\n+++ paper.tex\n" +
+				"@@ -1,1 +1,3 @@\n a\n+b\n+c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnifiedDiff("paper.tex", tt.before, tt.after); got != tt.expected {
+				t.Errorf("UnifiedDiff() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFix_Checker(t *testing.T) {
+	contents := `\begin{document}
+\begin{thebibliography}{9}
+\bibitem{a}
+	A. Author, \textquotedblleft{Caf\'e},\textquotedblright\ in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1–4,
+	% a comment
+	doi:  10.18429/JACoW-IPAC2023-MOPA001
+\bibitem{b}
+	B. Author et al., “Title”, \emph{Phys. Rev. Accel. Beams}, Vol. 26, Issue 4, 2023. \url{https://doi.org/10.1103/PhysRevAccelBeams.26.044601}
+\bibitem{c}
+	C. Author, “Title”, \url {10.1109/TMAG.2011.2172924}
+\end{thebibliography}
+\end{document}
+`
+	expected := `\begin{document}
+\begin{thebibliography}{9}
+\bibitem{a}
+	A. Author, \textquotedblleft{Caf\'e},\textquotedblright\ in \emph{Proc. IPAC'23}, Venice, Italy, May 2023, pp. 1–4,
+	% a comment
+	doi:10.18429/JACoW-IPAC2023-MOPA001
+\bibitem{b}
+	B. Author \emph{et al.}, “Title”, \emph{Phys. Rev. Accel. Beams}, vol. 26, no. 4, 2023. \url{doi:10.1103/PhysRevAccelBeams.26.044601}
+\bibitem{c}
+	C. Author, “Title”, \url {doi:10.1109/TMAG.2011.2172924}
+\end{thebibliography}
+\end{document}
+`
+	profile := checker.Profile{Disable: []string{"DOI_LOOKUP"}}
+	result := finder.Finder(structs.Request{Content: contents, Filename: "paper.tex"})
+	issues := checker.GetIssuesWithProfile(context.Background(), result, profile)
+	got := Fix("paper.tex", contents, issues)
+	if got.Fixed != expected {
+		t.Errorf("Fix() fixed = %v, want %v", got.Fixed, expected)
+	}
+	if len(got.Applied) != 5 || len(got.Skipped) != 0 {
+		t.Errorf("Fix() applied %d and skipped %d, want 5 and 0", len(got.Applied), len(got.Skipped))
+	}
+}
+
+func TestPatch(t *testing.T) {
+	contents := "\\bibitem{a}\nA. Author et al.\n"
+	issues := []structs.Issue{
+		{Name: "a", Type: "DOI_NOT_FOUND", Location: structs.Location{File: "paper.tex"}, Span: structs.Span{Start: structs.Position{Line: 2, Column: 1}}},
+		{Name: "a", Type: "ET_AL_NOT_WRAPPED", Fix: &structs.Edit{Location: structs.Location{File: "paper.tex", Start: 22, End: 28}, Replacement: "\\emph{et al.}"}},
+		{Name: "b", Type: "ET_AL_NOT_WRAPPED", Fix: &structs.Edit{Location: structs.Location{File: "refs.tex", Start: 0, End: 6}, Replacement: "\\emph{et al.}"}},
+	}
+	expected := "# paper.tex:2:1: DOI_NOT_FOUND [a] DOI was not found.\n" +
+		"\n" +
+		"--- paper.tex\n+++ paper.tex\n" +
+		"@@ -1,2 +1,2 @@\n \\bibitem{a}\n-A. Author et al.\n+A. Author \\emph{et al.}\n" +
+		"--- refs.tex\n+++ refs.tex\n" +
+		"@@ -1,1 +1,1 @@\n-et al.\n+\\emph{et al.}\n"
+	files := map[string]string{"paper.tex": contents, "refs.tex": "et al.\n"}
+	got := Patch(files, issues, func(issue structs.Issue) string {
+		return "DOI was not found."
+	})
+	if got != expected {
+		t.Errorf("Patch() = %q, want %q", got, expected)
+	}
+}
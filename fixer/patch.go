@@ -0,0 +1,47 @@
+package fixer
+
+import (
+	"catscan-latex/structs"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Patch returns the fixes suggested by the issues as a patch that can be
+// applied with patch -p0 from the root of the project. Issues which can not be
+// fixed automatically are listed above the diff as annotations, which patch
+// skips over, using describe to explain each issue.
+func Patch(files map[string]string, issues []structs.Issue, describe func(issue structs.Issue) string) string {
+	filenames := make([]string, 0, len(files))
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+
+	var annotations strings.Builder
+	for _, issue := range issues {
+		if issue.Fix == nil {
+			writeAnnotation(&annotations, issue, describe(issue))
+		}
+	}
+
+	var diffs strings.Builder
+	for _, filename := range filenames {
+		result := Fix(filename, files[filename], issues)
+		for _, issue := range result.Skipped {
+			writeAnnotation(&annotations, issue, "Overlaps another fix, please correct by hand. "+describe(issue))
+		}
+		diffs.WriteString(result.Diff)
+	}
+
+	if annotations.Len() > 0 && diffs.Len() > 0 {
+		annotations.WriteString("\n")
+	}
+	return annotations.String() + diffs.String()
+}
+
+func writeAnnotation(out *strings.Builder, issue structs.Issue, description string) {
+	name := strings.Trim(issue.Name, " \t\r\n")
+	description = strings.Join(strings.Fields(description), " ")
+	fmt.Fprintf(out, "# %s:%d:%d: %s [%s] %s\n", issue.Location.File, issue.Span.Start.Line, issue.Span.Start.Column, issue.Type, name, description)
+}
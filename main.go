@@ -1,22 +1,33 @@
 package main
 
 import (
+	"catscan-latex/archive"
 	"catscan-latex/checker"
 	"catscan-latex/finder"
+	"catscan-latex/fixer"
 	"catscan-latex/structs"
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/google/generative-ai-go/genai"
 	"github.com/rs/cors"
 	"google.golang.org/api/option"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
+	"sort"
 	"strings"
+	"syscall"
+	"time"
 )
 
-func issueToDescription(issue structs.Issue) string {
+func issueToDescription(issue structs.Issue, values checker.ProfileValues) string {
+	exampleDOI := values.ExampleDOI
 	switch issue.Type {
 	case "INCORRECT_STYLE_REFERENCE":
 		return "Reference does not appear to be in the JACoW style, please adjust your reference style to be consistent with the JACoW style reference, please see https://www.jacow.org/Authors/FormattingCitations"
@@ -24,31 +35,143 @@ func issueToDescription(issue structs.Issue) string {
 		return "et al. is preceded by a comma, which is incorrect. Please remove the comma before the et al."
 	case "ET_AL_NOT_WRAPPED":
 		return "et al. is not wrapped in a command to make it italic. Please use \\emph{et al.} instead of et al."
+	case "AUTHOR_NAME_ORDER":
+		return fmt.Sprintf("Authors are written with their initials before their surname, please write this author as %s.", issue.Suggestion)
+	case "AUTHOR_LIST_AND":
+		return "The last author should follow \"and\", for example A. One and B. Two, or A. One, B. Two, and C. Three."
+	case "AUTHOR_LIST_TOO_LONG":
+		return "References with more than six authors list only the first author followed by \\emph{et al.}, for example A. One \\emph{et al.}"
+	case "JOURNAL_NOT_ITALIC":
+		return "The journal or proceedings name should be in italics. Please wrap it in \\emph{}, for example \\emph{Phys. Rev. Accel. Beams}."
+	case "JOURNAL_NOT_ABBREVIATED":
+		return fmt.Sprintf("Journal names are abbreviated in JACoW references. Please use %s.", issue.Suggestion)
+	case "PROCEEDINGS_NAME_FORMAT":
+		return fmt.Sprintf("Conferences are written as their acronym and a two digit year with an apostrophe, please use %s.", issue.Suggestion)
+	case "PROCEEDINGS_MISSING_DATE":
+		return "Proceedings references include the month and year of the conference, for example in Proc. IPAC'23, Venice, Italy, May 2023."
+	case "PROCEEDINGS_MISSING_LOCATION":
+		return "Proceedings references include where the conference was held, for example in Proc. IPAC'23, Venice, Italy, May 2023."
+	case "PROCEEDINGS_YEAR_MISMATCH":
+		return fmt.Sprintf("This year does not match the year of the conference, which is %s. Please check the reference.", issue.Suggestion)
+	case "PAGES_NOT_EN_DASH":
+		return "Page ranges use an en-dash. Please write the range with -- instead of -, for example pp. 1234--1237."
+	case "JACOW_DOI_FORMAT":
+		if issue.Suggestion != "" {
+			return fmt.Sprintf("This JACoW DOI is not in the format 10.18429/JACoW-<CONF><YEAR>-<PAPERID>. It is likely to be %s.", issue.Suggestion)
+		}
+		return fmt.Sprintf("This JACoW DOI is not in the format 10.18429/JACoW-<CONF><YEAR>-<PAPERID>, for example %s. Please check the DOI.", exampleDOI)
+	case "JACOW_DOI_UNKNOWN_CONFERENCE":
+		return jacowDOIDescription("This JACoW DOI names a conference series that does not publish with JACoW.", issue)
+	case "JACOW_DOI_UNKNOWN_YEAR":
+		return jacowDOIDescription("This JACoW DOI names a year in which the conference was not held, or its proceedings have no DOIs.", issue)
+	case "JACOW_DOI_PAPER_ID":
+		return jacowDOIDescription("The paper ID in this JACoW DOI is not valid. Paper IDs start with the day, followed by the session and the paper number, for example MOPA012 or THXD3.", issue)
+	case "PROCEEDINGS_DOI_MISMATCH":
+		return fmt.Sprintf("This DOI is for %s, which does not match the conference named in the reference. Please check the DOI and the conference.", issue.Suggestion)
 	case "DOI_CONTAINS_SPACE":
 		return "DOI contains a space after the colon. Please remove the space."
 	case "DOI_NOT_WRAPPED":
-		return "DOI not wrapped in \\url{} command. Please use \\url{doi:10.18429/JACoW-IPAC2023-XXXX} instead of doi:10.18429/JACoW-IPAC2023-XXXX"
+		return fmt.Sprintf("DOI not wrapped in \\url{} command. Please use \\url{doi:%s} instead of doi:%s", exampleDOI, exampleDOI)
 	case "NO_DOI_PREFIX":
-		return "DOI does not contain \"doi:\" prefix. It should appear like this \\url{doi:10.18429/JACoW-IPAC2023-XXXX}"
+		return fmt.Sprintf("DOI does not contain \"doi:\" prefix. It should appear like this \\url{doi:%s}", exampleDOI)
 	case "DOI_IS_URL":
-		return "DOI is written as a web URL (including https://doi.org/) which is incorrect. Remove the https://doi.org/, and write it as per this example. \\url{doi:10.18429/JACoW-IPAC2023-XXXX}"
+		return fmt.Sprintf("DOI is written as a web URL (including https://doi.org/) which is incorrect. Remove the https://doi.org/, and write it as per this example. \\url{doi:%s}", exampleDOI)
+	case "DOI_URL_NO_SCHEME":
+		if issue.Suggestion != "" {
+			return fmt.Sprintf("DOI is written as a doi.org link without https://, which is not a valid link. Please write it with the doi: prefix instead, like this %s", issue.Suggestion)
+		}
+		return fmt.Sprintf("DOI is written as a doi.org link without https://, which is not a valid link. Please write it with the doi: prefix instead, like this \\url{doi:%s}", exampleDOI)
+	case "URL_NOT_WRAPPED":
+		return fmt.Sprintf("This link is not wrapped in a \\url{} command, so it may not break across lines or be clickable. Please write it like this %s", issue.Suggestion)
+	case "ARXIV_ID_FORMAT":
+		return "This arXiv identifier is not valid. Identifiers since April 2007 are written like arXiv:2101.01234, and older ones like arXiv:hep-th/9901001."
+	case "ARXIV_ID_SCHEME":
+		if issue.Suggestion != "" {
+			return fmt.Sprintf("This arXiv identifier has the wrong number of digits for its date, as identifiers have five digit numbers since January 2015. It is most likely %s.", issue.Suggestion)
+		}
+		return "This arXiv identifier uses the wrong scheme for its date. Identifiers since April 2007 are written like arXiv:2101.01234, and older ones like arXiv:hep-th/9901001. Please check the identifier."
+	case "ISBN_FORMAT":
+		return "This ISBN does not have 10 digits, or 13 digits starting 978 or 979. Please check the ISBN."
+	case "ISBN_CHECKSUM":
+		return fmt.Sprintf("The check digit of this ISBN is wrong, so one of its digits is mistyped. If the other digits are right, the ISBN is %s.", issue.Suggestion)
 	case "VOLUME_ISSUE":
 		return "JACoW references use vol. X and no. X. You have used not Vol. X, Issue X, which is incorrect. Please correct your reference style. You can generate correctly formatted references at https://refs.jacow.org/ or you can refer to the JACoW reference style guide at https://www.jacow.org/Authors/FormattingCitations"
 	case "DOI_ENDS_IN_PERIOD":
 		return "This DOI ends in a period, which is incorrect for this specific DOI. Please remove the period."
 	case "DOI_ENDS_IN_PARENTHESIS":
 		return "DOI is wrapped in parenthesis, please remove these."
+	case "DOI_ENDS_IN_PUNCTUATION":
+		return fmt.Sprintf("This DOI is followed by punctuation that is not part of it. Please remove the punctuation, so the DOI is %s.", issue.Suggestion)
+	case "METADATA_TITLE_MISMATCH":
+		return fmt.Sprintf("This title differs from the one registered for the DOI, \"%s\". Please check that the DOI and the title are of the same paper.", issue.Suggestion)
+	case "METADATA_AUTHOR_MISMATCH":
+		return fmt.Sprintf("The first author differs from the one registered for the DOI, %s. Please check that the DOI and the authors are of the same paper.", issue.Suggestion)
+	case "METADATA_YEAR_MISMATCH":
+		return fmt.Sprintf("This year differs from the one registered for the DOI, %s. Please check the year and the DOI.", issue.Suggestion)
+	case "METADATA_VOLUME_MISMATCH":
+		return fmt.Sprintf("This volume differs from the one registered for the DOI, %s. Please check the volume and the DOI.", issue.Suggestion)
+	case "METADATA_PAGES_MISMATCH":
+		return fmt.Sprintf("These pages differ from those registered for the DOI, %s. Please check the pages and the DOI.", issue.Suggestion)
+	case "DOI_UNVERIFIED":
+		return "This DOI could not be checked, because the DOI resolver could not be reached or was too busy. Please check that the DOI is correct."
 	case "DOI_NOT_FOUND":
+		if issue.Suggestion != "" {
+			return fmt.Sprintf("DOI was checked, and does not appear to be valid. It is likely to be %s. Please check if the DOI is correct.", issue.Suggestion)
+		}
 		return "DOI was checked, and does not appear to be valid. Please check if the DOI is correct."
+	case "INCLUDE_NOT_FOUND":
+		return "This file is included, but was not uploaded, so it has not been checked. Please include all files in your submission."
+	case "INCLUDE_CYCLE":
+		return fmt.Sprintf("This file includes itself, which LaTeX can not compile. Please remove the include (%s).", issue.Suggestion)
+	case "BIBITEM_NOT_CITED":
+		return "This reference is never cited in the text. Every reference must be cited, please cite it or remove it."
+	case "CITATION_NOT_FOUND":
+		return fmt.Sprintf("There is no reference with the key %s, so this citation will appear as [?]. Please add the reference or correct the key.", issue.Name)
+	case "CITATION_ORDER":
+		return fmt.Sprintf("References must be numbered in the order they are first cited, so this reference should be %s. Please reorder the bibliography.", issue.Suggestion)
+	case "MISSING_DOI":
+		return fmt.Sprintf("This reference has no DOI, but a past reference with the same title and authors has one. If it is the same work, please add its DOI like this %s", issue.Suggestion)
+	case "BIBTEX_MISSING_DOI":
+		return fmt.Sprintf("This .bib entry has no doi field. If the work has a DOI, please add it like this doi = {%s}", exampleDOI)
+	case "BIBTEX_DOI_IN_URL":
+		return fmt.Sprintf("This .bib entry gives its DOI in the url field. Please put it in the doi field instead, like this doi = {%s}", exampleDOI)
+	case "BIBTEX_WRONG_ENTRY_TYPE":
+		return "This .bib entry is for a conference paper, but is not an @inproceedings entry, so it will not be formatted as JACoW expects. Please use @inproceedings with a booktitle."
 	}
 	return ""
 }
 
+// jacowDOIDescription explains a JACoW DOI problem, naming the DOI that was most
+// likely meant when it is known.
+func jacowDOIDescription(problem string, issue structs.Issue) string {
+	if issue.Suggestion != "" {
+		return fmt.Sprintf("%s It is likely to be %s.", problem, issue.Suggestion)
+	}
+	return problem + " Please check the DOI."
+}
+
+// apiVersion identifies the schema of Response, and must be bumped whenever
+// fields are removed or change meaning.
+const apiVersion = "1"
+
 type Request struct {
 	Filename string `json:"filename"`
 	Content  string `json:"content"`
+	Profile  string `json:"profile"`
+	// Mode is either empty, to only report issues, or "fix" to also return
+	// the contents with every mechanical fix applied.
+	Mode string `json:"mode"`
+	// Reorder, in fix mode, also puts the bibliography in the order the
+	// references are first cited, once the other fixes are applied.
+	Reorder bool `json:"reorder"`
+	// Files, when given, are all the files of a project, with Main naming
+	// the file passed to LaTeX. Filename and Content are then ignored.
+	Files map[string]string `json:"files"`
+	Main  string            `json:"main"`
 }
 
+const modeFix = "fix"
+
 type Response struct {
 	StatusCode    int               `json:"statusCode,omitempty"`
 	Headers       map[string]string `json:"headers,omitempty"`
@@ -56,6 +179,61 @@ type Response struct {
 	IsAbbreviated bool              `json:"isabbreviated"`
 	IssuesFound   int               `json:"issuesFound"`
 	Unabbreviated string            `json:"unabbreviated"`
+	APIVersion    string            `json:"apiVersion"`
+	Issues        []IssueEntry      `json:"issues"`
+	Fixed         string            `json:"fixed,omitempty"`
+	FixedFiles    map[string]string `json:"fixedFiles,omitempty"`
+	Diff          string            `json:"diff,omitempty"`
+	Main          string            `json:"main,omitempty"`
+}
+
+type IssueEntry struct {
+	Code        string `json:"code"`
+	Severity    string `json:"severity"`
+	Message     string `json:"message"`
+	Name        string `json:"name"`
+	File        string `json:"file"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	StartLine   int    `json:"startLine"`
+	StartColumn int    `json:"startColumn"`
+	EndLine     int    `json:"endLine"`
+	EndColumn   int    `json:"endColumn"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	Fix         *Fix   `json:"fix,omitempty"`
+}
+
+type Fix struct {
+	File        string `json:"file"`
+	Start       int    `json:"start"`
+	End         int    `json:"end"`
+	Replacement string `json:"replacement"`
+}
+
+func toIssueEntries(issues []structs.Issue, values checker.ProfileValues) []IssueEntry {
+	entries := make([]IssueEntry, 0, len(issues))
+	for _, issue := range issues {
+		var fix *Fix
+		if issue.Fix != nil {
+			fix = &Fix{File: issue.Fix.Location.File, Start: issue.Fix.Location.Start, End: issue.Fix.Location.End, Replacement: issue.Fix.Replacement}
+		}
+		entries = append(entries, IssueEntry{
+			Code:        issue.Type,
+			Severity:    string(issue.Severity),
+			Message:     issueToDescription(issue, values),
+			Name:        strings.Trim(issue.Name, " \t\r\n"),
+			File:        issue.Location.File,
+			Start:       issue.Location.Start,
+			End:         issue.Location.End,
+			StartLine:   issue.Span.Start.Line,
+			StartColumn: issue.Span.Start.Column,
+			EndLine:     issue.Span.End.Line,
+			EndColumn:   issue.Span.End.Column,
+			Suggestion:  issue.Suggestion,
+			Fix:         fix,
+		})
+	}
+	return entries
 }
 
 func geminiSummarize(content string) (string, error) {
@@ -103,17 +281,18 @@ type Report struct {
 	issues        []structs.Issue
 }
 
-func getReport(issues []structs.Issue) Report {
+func getReport(issues []structs.Issue, values checker.ProfileValues) Report {
 	report := Report{
 		issueFound:    false,
 		issueCount:    0,
 		output:        "No issues found",
 		unabbreviated: "",
+		issues:        issues,
 	}
 	for _, issue := range issues {
 		report.issueFound = true
 		name := strings.Trim(issue.Name, " \t\r\n")
-		descriptionOfIssue := issueToDescription(issue)
+		descriptionOfIssue := issueToDescription(issue, values)
 		if descriptionOfIssue != "" {
 			report.issueFound = true
 			report.issueCount += 1
@@ -124,15 +303,50 @@ func getReport(issues []structs.Issue) Report {
 	return report
 }
 
-func Main(in Request) (*Response, error) {
-	fileName := in.Filename
-	contents := in.Content
-	isAbbreviated := false
-	// for each file, read the contents and run the main function
-	result := finder.Finder(structs.Request{Content: contents, Filename: fileName})
+// processingErrorStatus is the status of a request that could not be
+// processed. A project that expands to too much is refused rather than a fault.
+func processingErrorStatus(err error) int {
+	if errors.Is(err, finder.ErrProjectTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
 
-	issues := checker.GetIssues(result)
-	report := getReport(issues)
+// checkRequest runs the finder and checker over the file, or project, in the
+// request. It returns every file checked, by name, along with the issues. DOI
+// lookups are abandoned once ctx is done.
+func checkRequest(ctx context.Context, in Request) (map[string]string, []structs.Issue, checker.Profile, error) {
+	profile, ok := checker.LookupProfile(in.Profile)
+	if !ok {
+		return nil, nil, profile, fmt.Errorf("unknown profile %s", in.Profile)
+	}
+	if len(in.Files) == 0 {
+		result := finder.Finder(structs.Request{Content: in.Content, Filename: in.Filename})
+		files := map[string]string{in.Filename: in.Content}
+		return files, checker.GetIssuesWithProfile(ctx, result, profile), profile, nil
+	}
+
+	mainFile := in.Main
+	if mainFile == "" {
+		mainFile = in.Filename
+	}
+	if _, ok := in.Files[mainFile]; !ok {
+		return nil, nil, profile, fmt.Errorf("main file %q is not one of the files", mainFile)
+	}
+	result, err := finder.FinderProject(structs.Project{Main: mainFile, Files: in.Files})
+	if err != nil {
+		return nil, nil, profile, err
+	}
+	return in.Files, checker.GetIssuesWithProfile(ctx, result, profile), profile, nil
+}
+
+func Main(ctx context.Context, in Request) (*Response, error) {
+	isAbbreviated := false
+	files, issues, profile, err := checkRequest(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	report := getReport(issues, profile.Values)
 
 	if report.issueFound {
 		report.output = report.unabbreviated
@@ -145,13 +359,65 @@ func Main(in Request) (*Response, error) {
 		}
 	}
 
-	return &Response{
+	response := &Response{
 		StatusCode:    200,
 		Body:          report.output,
 		IsAbbreviated: isAbbreviated,
 		IssuesFound:   report.issueCount,
 		Unabbreviated: report.unabbreviated,
-	}, nil
+		APIVersion:    apiVersion,
+		Issues:        toIssueEntries(report.issues, profile.Values),
+	}
+	if in.Mode == modeFix {
+		fixedFiles := make(map[string]string)
+		for name, content := range files {
+			fixedFiles[name] = fixer.Fix(name, content, issues).Fixed
+		}
+		if in.Reorder {
+			mainFile := in.Main
+			if mainFile == "" {
+				mainFile = in.Filename
+			}
+			reorderBibliography(fixedFiles, mainFile, len(in.Files) > 0)
+		}
+		if len(in.Files) == 0 {
+			response.Fixed = fixedFiles[in.Filename]
+			response.Diff = fixer.UnifiedDiff(in.Filename, in.Content, response.Fixed)
+		} else {
+			names := make([]string, 0, len(files))
+			for name := range files {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			response.FixedFiles = make(map[string]string)
+			for _, name := range names {
+				if diff := fixer.UnifiedDiff(name, files[name], fixedFiles[name]); diff != "" {
+					response.FixedFiles[name] = fixedFiles[name]
+					response.Diff += diff
+				}
+			}
+		}
+	}
+	return response, nil
+}
+
+// reorderBibliography puts the bibliography of the fixed files in citation
+// order. It runs once the other fixes are applied, as it replaces the whole
+// bibliography, so they are kept.
+func reorderBibliography(files map[string]string, mainFile string, isProject bool) {
+	var result structs.Contents
+	if isProject {
+		var err error
+		if result, err = finder.FinderProject(structs.Project{Main: mainFile, Files: files}); err != nil {
+			return
+		}
+	} else {
+		result = finder.Finder(structs.Request{Content: files[mainFile], Filename: mainFile})
+	}
+	if edit := checker.CitationOrderFix(result); edit != nil {
+		content := files[edit.Location.File]
+		files[edit.Location.File] = content[:edit.Location.Start] + edit.Replacement + content[edit.Location.End:]
+	}
 }
 
 func baseHandler(w http.ResponseWriter, r *http.Request) {
@@ -176,10 +442,33 @@ func baseHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := checker.LookupProfile(req.Profile); !ok {
+		http.Error(w, fmt.Sprintf("Unknown profile %s, expected one of %s", req.Profile, strings.Join(checker.ProfileNames(), ", ")), http.StatusBadRequest)
+		return
+	}
+
 	// Call the Main function
-	resp, err := Main(req)
+	resp, err := Main(r.Context(), req)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error processing request: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Error processing request: %v", err), processingErrorStatus(err))
+		return
+	}
+
+	// In fix mode, ?download returns the corrected file instead of JSON
+	if req.Mode == modeFix && r.URL.Query().Has("download") {
+		if len(req.Files) > 0 {
+			http.Error(w, "Download is only available when checking a single file", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-tex; charset=utf-8")
+		downloadName := path.Base(req.Filename)
+		if req.Filename == "" {
+			downloadName = "fixed.tex"
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", downloadName))
+		if _, err := w.Write([]byte(resp.Fixed)); err != nil {
+			log.Printf("Error writing fixed file: %v", err)
+		}
 		return
 	}
 
@@ -190,9 +479,280 @@ func baseHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// maxUploadSize is the largest zip archive accepted by uploadHandler.
+const maxUploadSize = 50 << 20
+
+// uploadHandler checks a paper uploaded as a zip archive, sent either as the
+// "file" field of a multipart form or as the body of the request. The profile
+// and mode can be given as form fields or query parameters.
+func uploadHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request: %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize)
+	data, err := readUpload(r)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading upload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	files, err := archive.ReadZip(data, archive.DefaultLimits)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error reading archive: %v", err), http.StatusBadRequest)
+		return
+	}
+	mainFile, err := finder.FindMainFile(files)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error finding main file: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	req := Request{
+		Filename: mainFile,
+		Files:    files,
+		Main:     mainFile,
+		Profile:  r.FormValue("profile"),
+		Mode:     r.FormValue("mode"),
+	}
+	if _, ok := checker.LookupProfile(req.Profile); !ok {
+		http.Error(w, fmt.Sprintf("Unknown profile %s, expected one of %s", req.Profile, strings.Join(checker.ProfileNames(), ", ")), http.StatusBadRequest)
+		return
+	}
+
+	resp, err := Main(r.Context(), req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error processing request: %v", err), processingErrorStatus(err))
+		return
+	}
+	resp.Main = mainFile
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+func readUpload(r *http.Request) ([]byte, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return io.ReadAll(file)
+	}
+	return io.ReadAll(r.Body)
+}
+
+// patchHandler responds with a unified diff of the suggested fixes, which can be
+// applied to the submitted file with patch -p0.
+func patchHandler(w http.ResponseWriter, r *http.Request) {
+	log.Printf("Received request: %s %s", r.Method, r.URL.Path)
+
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid JSON: %v", err), http.StatusBadRequest)
+		return
+	}
+	if req.Filename == "" && len(req.Files) == 0 {
+		http.Error(w, "A filename is required to generate a patch", http.StatusBadRequest)
+		return
+	}
+
+	files, issues, profile, err := checkRequest(r.Context(), req)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Error processing request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	patch := fixer.Patch(files, issues, func(issue structs.Issue) string {
+		return issueToDescription(issue, profile.Values)
+	})
+	w.Header().Set("Content-Type", "text/x-diff; charset=utf-8")
+	if _, err := w.Write([]byte(patch)); err != nil {
+		log.Printf("Error writing patch: %v", err)
+	}
+}
+
+type RuleEntry struct {
+	ID          string   `json:"id"`
+	Description string   `json:"description"`
+	Severity    string   `json:"severity"`
+	Scope       string   `json:"scope"`
+	IssueTypes  []string `json:"issueTypes"`
+	Enabled     bool     `json:"enabled"`
+}
+
+func rulesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET method is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	entries := make([]RuleEntry, 0)
+	for _, rule := range checker.DefaultRegistry.Rules() {
+		entries = append(entries, RuleEntry{
+			ID:          rule.ID(),
+			Description: rule.Description(),
+			Severity:    string(rule.Severity()),
+			Scope:       string(rule.Scope()),
+			IssueTypes:  rule.IssueTypes(),
+			Enabled:     checker.DefaultRegistry.IsEnabled(rule.ID()),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// durationEnv reads a duration, such as 720h, from an environment variable, or
+// returns the default when it is not set.
+func durationEnv(name string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("%s: %v", name, err)
+	}
+	return duration
+}
+
+// doiCacheHandler lists the remembered DOI lookups on GET, and forgets them on
+// DELETE, either every one or the one given by the doi query parameter. As the
+// server allows requests from any origin, DELETE needs adminToken as a bearer
+// token, and is refused when no token is configured.
+func doiCacheHandler(cache *checker.CachedResolver, adminToken string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		log.Printf("Received request: %s %s", r.Method, r.URL.Path)
+
+		var body any
+		switch r.Method {
+		case http.MethodGet:
+			body = cache.Entries()
+		case http.MethodDelete:
+			if !isAdmin(r, adminToken) {
+				http.Error(w, "Purging the DOI cache needs the admin token", http.StatusForbidden)
+				return
+			}
+			removed, err := cache.Purge(r.URL.Query().Get("doi"))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error purging DOI cache: %v", err), http.StatusInternalServerError)
+				return
+			}
+			body = map[string]int{"removed": removed}
+		default:
+			http.Error(w, "Only GET and DELETE methods are allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(body); err != nil {
+			http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
+		}
+	}
+}
+
+// configureRules applies the comma separated rule IDs in the ENABLED_RULES
+// and DISABLED_RULES environment variables to the default registry.
+func configureRules() {
+	for _, id := range strings.Split(os.Getenv("ENABLED_RULES"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			if err := checker.DefaultRegistry.Enable(id); err != nil {
+				log.Fatalf("ENABLED_RULES: %v", err)
+			}
+		}
+	}
+	for _, id := range strings.Split(os.Getenv("DISABLED_RULES"), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			if err := checker.DefaultRegistry.Disable(id); err != nil {
+				log.Fatalf("DISABLED_RULES: %v", err)
+			}
+		}
+	}
+}
+
+// isAdmin reports whether a request has the admin token as its bearer token.
+// Without a token nobody is an admin.
+func isAdmin(r *http.Request, adminToken string) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+}
+
+// shutdownTimeout is how long requests being checked are given to finish when
+// the server is stopped.
+const shutdownTimeout = 30 * time.Second
+
 func main() {
+	configureRules()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if profilesFile := os.Getenv("PROFILES_FILE"); profilesFile != "" {
+		if err := checker.LoadProfiles(profilesFile); err != nil {
+			log.Fatalf("Error loading profiles: %v", err)
+		}
+	}
+	resolverURL := os.Getenv("DOI_RESOLVER_URL")
+	if resolverURL == "" {
+		resolverURL = checker.DOIOrgURL
+	}
+	var resolver checker.DOIResolver = checker.NewHTTPResolver(resolverURL)
+	var doiCache *checker.CachedResolver
+	if cacheFile := os.Getenv("DOI_CACHE_FILE"); cacheFile != "" {
+		var err error
+		doiCache, err = checker.NewCachedResolver(resolver, cacheFile, durationEnv("DOI_CACHE_TTL", checker.DefaultPositiveTTL), durationEnv("DOI_CACHE_NEGATIVE_TTL", checker.DefaultNegativeTTL))
+		if err != nil {
+			log.Fatalf("Error loading DOI cache: %v", err)
+		}
+		resolver = doiCache
+		go doiCache.FlushEvery(ctx, checker.DefaultFlushInterval)
+	}
+	checker.DefaultRegistry.SetDOIResolver(resolver)
+	if fixtureFile := os.Getenv("METADATA_FIXTURE"); fixtureFile != "" {
+		fixture, err := checker.LoadMetadataFixture(fixtureFile)
+		if err != nil {
+			log.Fatalf("Error loading metadata fixture: %v", err)
+		}
+		checker.DefaultRegistry.SetMetadataProvider(fixture)
+	} else if crossrefURL := os.Getenv("CROSSREF_URL"); crossrefURL != "" {
+		checker.DefaultRegistry.SetMetadataProvider(checker.NewCrossrefProvider(crossrefURL, os.Getenv("CROSSREF_MAILTO")))
+	}
+	if indexFile := os.Getenv("DOI_INDEX_FILE"); indexFile != "" {
+		index, err := checker.LoadCorpusIndex(indexFile)
+		if err != nil {
+			log.Fatalf("Error loading DOI index: %v", err)
+		}
+		checker.DefaultRegistry.SetDOISearcher(index)
+	}
+	if conferencesFile := os.Getenv("JACOW_CONFERENCES_FILE"); conferencesFile != "" {
+		if err := checker.LoadJacowConferences(conferencesFile); err != nil {
+			log.Fatalf("Error loading JACoW conferences: %v", err)
+		}
+	}
+
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", baseHandler)
+	mux.HandleFunc("/rules", rulesHandler)
+	mux.HandleFunc("/patch", patchHandler)
+	mux.HandleFunc("/upload", uploadHandler)
+	if doiCache != nil {
+		mux.HandleFunc("/doi-cache", doiCacheHandler(doiCache, os.Getenv("DOI_CACHE_ADMIN_TOKEN")))
+	}
 
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Or specifically list your frontend domains
@@ -210,8 +770,28 @@ func main() {
 
 	bindAddr := fmt.Sprintf(":%s", port)
 
-	log.Printf("Starting server on %s", bindAddr)
-	if err := http.ListenAndServe(bindAddr, corsHandler); err != nil {
+	server := &http.Server{Addr: bindAddr, Handler: corsHandler}
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on %s", bindAddr)
+		serverErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatalf("Server failed: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
+	log.Printf("Shutting down server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	if doiCache != nil {
+		if err := doiCache.Close(); err != nil {
+			log.Printf("Error saving DOI cache: %v", err)
+		}
 	}
 }
@@ -5,6 +5,8 @@ import (
 	"catscan-latex/checker"
 	"catscan-latex/finder"
 	"catscan-latex/structs"
+	"context"
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -18,8 +20,67 @@ type detailEntry struct {
 }
 
 func main() {
+	profileName := flag.String("profile", checker.DefaultProfileName, "name of the conference profile to check against")
+	profilesFile := flag.String("profiles", "", "YAML or JSON file of additional profiles")
+	conferencesFile := flag.String("conferences", "", "YAML or JSON file of JACoW conference series and years")
+	resolverURL := flag.String("doi-resolver", checker.DOIOrgURL, "base URL DOIs are looked up at")
+	cacheFile := flag.String("doi-cache", "stats/doi_cache.json", "JSON file DOI lookups are remembered in, or empty to always look up")
+	positiveTTL := flag.Duration("doi-cache-ttl", checker.DefaultPositiveTTL, "how long a DOI that resolved is remembered")
+	negativeTTL := flag.Duration("doi-cache-negative-ttl", checker.DefaultNegativeTTL, "how long a DOI that did not resolve is remembered")
+	metadataFixture := flag.String("metadata-fixture", "", "JSON file of Crossref works to compare references with, instead of Crossref")
+	crossrefMailto := flag.String("crossref-mailto", "", "email address sent to Crossref with metadata requests")
+	indexFile := flag.String("doi-index", "", "JSON file of past references searched for missing DOIs, which the references checked are added to")
+	flag.Parse()
+
+	var resolver checker.DOIResolver = checker.NewHTTPResolver(*resolverURL)
+	var doiCache *checker.CachedResolver
+	if *cacheFile != "" {
+		var err error
+		doiCache, err = checker.NewCachedResolver(resolver, *cacheFile, *positiveTTL, *negativeTTL)
+		if err != nil {
+			log.Fatalf("Error loading DOI cache: %v", err)
+		}
+		resolver = doiCache
+	}
+	checker.DefaultRegistry.SetDOIResolver(resolver)
+	if *metadataFixture != "" {
+		fixture, err := checker.LoadMetadataFixture(*metadataFixture)
+		if err != nil {
+			log.Fatalf("Error loading metadata fixture: %v", err)
+		}
+		checker.DefaultRegistry.SetMetadataProvider(fixture)
+	} else {
+		checker.DefaultRegistry.SetMetadataProvider(checker.NewCrossrefProvider(checker.CrossrefURL, *crossrefMailto))
+	}
+
+	var index *checker.CorpusIndex
+	if *indexFile != "" {
+		var err error
+		index, err = checker.LoadCorpusIndex(*indexFile)
+		if err != nil {
+			log.Fatalf("Error loading DOI index: %v", err)
+		}
+		checker.DefaultRegistry.SetDOISearcher(index)
+	}
+
+	if *profilesFile != "" {
+		if err := checker.LoadProfiles(*profilesFile); err != nil {
+			log.Fatalf("Error loading profiles: %v", err)
+		}
+	}
+	if *conferencesFile != "" {
+		if err := checker.LoadJacowConferences(*conferencesFile); err != nil {
+			log.Fatalf("Error loading JACoW conferences: %v", err)
+		}
+	}
+	profile, ok := checker.LookupProfile(*profileName)
+	if !ok {
+		log.Fatalf("Unknown profile '%v'", *profileName)
+	}
+
 	files := findFiles("examples")
 	details := make([]detailEntry, 0)
+	var checked []structs.Contents
 	for _, fileName := range files {
 		fmt.Printf("Checking %s\n", fileName)
 		contents, err := getContents(fileName)
@@ -31,7 +92,7 @@ func main() {
 
 		entry := detailEntry{
 			FileName: fileName,
-			Issues:   checker.GetIssues(result),
+			Issues:   checker.GetIssuesWithProfile(context.Background(), result, profile),
 		}
 
 		sort.Slice(entry.Issues, func(i, j int) bool {
@@ -39,6 +100,25 @@ func main() {
 		})
 
 		details = append(details, entry)
+		checked = append(checked, result)
+	}
+	if doiCache != nil {
+		if err := doiCache.Close(); err != nil {
+			log.Fatalf("Error saving DOI cache: %v", err)
+		}
+	}
+
+	// references are only added once every file is checked, so the DOIs
+	// suggested do not depend on the order of the files
+	if index != nil {
+		added := 0
+		for i, result := range checked {
+			added += index.AddBibItems(result.BibItems, details[i].Issues)
+		}
+		if err := index.Save(*indexFile); err != nil {
+			log.Fatalf("Error saving DOI index: %v", err)
+		}
+		log.Printf("%d references added to '%v', which has %d", added, *indexFile, index.Len())
 	}
 
 	sort.Slice(details, func(i, j int) bool {
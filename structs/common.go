@@ -13,20 +13,94 @@ type Citation struct {
 	Location Location `json:"location"`
 }
 
+// BibItem is a reference, either from a \bibitem or an entry in a .bib file.
+// EntryType, EntryTypeLocation and Fields are only set for .bib entries.
+// Parsed holds the fields found in the reference by the reference parser.
+// Doi is the DOI as written, and NormalisedDoi the DOI it names, without
+// escapes or trailing punctuation. NormalisedDoiLocation is the part of
+// DoiLocation it was read from.
 type BibItem struct {
-	Name          string   `json:"-"`
-	OriginalText  string   `json:"-"`
-	Doi           string   `json:"doi"`
-	Ref           string   `json:"ref"`
+	Name                  string     `json:"-"`
+	OriginalText          string     `json:"-"`
+	Doi                   string     `json:"doi"`
+	DoiLocation           Location   `json:"doiLocation"`
+	NormalisedDoi         string     `json:"normalisedDoi"`
+	NormalisedDoiLocation Location   `json:"normalisedDoiLocation"`
+	Ref                   string     `json:"ref"`
+	RefOffsets            []int      `json:"-"`
+	Location              Location   `json:"location"`
+	LabelLocation         Location   `json:"labelLocation"`
+	EntryType             string     `json:"entryType,omitempty"`
+	EntryTypeLocation     Location   `json:"-"`
+	Fields                []BibField `json:"fields,omitempty"`
+	Parsed                Reference  `json:"parsed"`
+}
+
+// BibField is a field of a .bib entry. Location is of the value, without its
+// delimiters, and FieldLocation of the whole field, from its name to its value.
+type BibField struct {
+	Name          string   `json:"name"`
+	Value         string   `json:"value"`
 	Location      Location `json:"location"`
-	LabelLocation Location `json:"labelLocation"`
+	FieldLocation Location `json:"fieldLocation"`
+}
+
+func (b BibItem) IsBibTeX() bool {
+	return b.EntryType != ""
+}
+
+// Field finds a field of a .bib entry by its lower case name.
+func (b BibItem) Field(name string) (BibField, bool) {
+	for _, field := range b.Fields {
+		if field.Name == name {
+			return field, true
+		}
+	}
+	return BibField{}, false
+}
+
+// RefLocation converts a range of bytes in Ref, which has had comments and
+// excess whitespace removed, back to a location in the original contents.
+// When RefOffsets is not known, Ref is assumed to start at Location.Start.
+func (b BibItem) RefLocation(start int, end int) Location {
+	if len(b.RefOffsets) == 0 {
+		return Location{File: b.Location.File, Start: b.Location.Start + start, End: b.Location.Start + end}
+	}
+	offsetOf := func(i int) int {
+		if i >= len(b.RefOffsets) {
+			return b.RefOffsets[len(b.RefOffsets)-1] + 1
+		}
+		return b.RefOffsets[i]
+	}
+	if end <= start {
+		return Location{File: b.Location.File, Start: offsetOf(start), End: offsetOf(start)}
+	}
+	return Location{File: b.Location.File, Start: offsetOf(start), End: offsetOf(end-1) + 1}
+}
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Edit replaces the text at Location with Replacement. An empty location
+// inserts the replacement.
+type Edit struct {
+	Location    Location `json:"location"`
+	Replacement string   `json:"replacement"`
 }
 
 type Issue struct {
 	Name       string   `json:"name"`
 	Type       string   `json:"type"`
+	Severity   Severity `json:"severity"`
 	Location   Location `json:"location"`
+	Span       Span     `json:"span"`
 	Suggestion string   `json:"suggestion"`
+	Fix        *Edit    `json:"fix,omitempty"`
 }
 
 type CheckResult int
@@ -41,10 +115,72 @@ type Request struct {
 	Content  string `json:"content"`
 }
 
+// Project is a paper split across several files. Main is the name of the file
+// passed to LaTeX, and file names are relative to the root of the project.
+type Project struct {
+	Main  string            `json:"main"`
+	Files map[string]string `json:"files"`
+}
+
+// Segment is a range of the flattened contents of a project, copied from
+// Offset onwards in File.
+type Segment struct {
+	File   string `json:"file"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Offset int    `json:"offset"`
+}
+
+// Include is an \input, \include or \subfile command. Name is the file name
+// as written, and File the file in the project it resolved to. Error is set
+// when the file could not be included.
+type Include struct {
+	Command  string   `json:"command"`
+	Name     string   `json:"name"`
+	File     string   `json:"file"`
+	Location Location `json:"location"`
+	Error    string   `json:"error,omitempty"`
+}
+
 type Contents struct {
-	Filename string    `json:"filename"`
-	Content  string    `json:"content"`
-	BibItems []BibItem `json:"bibItems"`
-	Document Document  `json:"-"`
-	Comments []Comment `json:"-"`
+	Filename  string            `json:"filename"`
+	Content   string            `json:"content"`
+	BibItems  []BibItem         `json:"bibItems"`
+	Citations []Citation        `json:"citations"`
+	Includes  []Include         `json:"includes"`
+	Document  Document          `json:"-"`
+	Comments  []Comment         `json:"-"`
+	Segments  []Segment         `json:"-"`
+	Files     map[string]string `json:"-"`
+}
+
+// Resolve converts a location in Content to a location in the file it came
+// from. Locations which already have a file are returned unchanged, and a
+// location spanning more than one file is cut short at the end of the first.
+func (c Contents) Resolve(location Location) Location {
+	if location.File != "" {
+		return location
+	}
+	for _, segment := range c.Segments {
+		if location.Start >= segment.Start && (location.Start < segment.End || location.Start == segment.End && location.End == segment.End) {
+			return Location{
+				File:  segment.File,
+				Start: segment.Offset + location.Start - segment.Start,
+				End:   segment.Offset + min(location.End, segment.End) - segment.Start,
+			}
+		}
+	}
+	location.File = c.Filename
+	return location
+}
+
+// FileContent returns the contents of a file in the project.
+func (c Contents) FileContent(file string) string {
+	if content, ok := c.Files[file]; ok {
+		return content
+	}
+	if file == c.Filename && len(c.Segments) == 0 {
+		return c.Content
+	}
+	return ""
 }
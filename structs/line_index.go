@@ -0,0 +1,51 @@
+package structs
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// Position is a 1-based line and column, with columns counted in runes.
+type Position struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// LineIndex maps byte offsets in a file to line and column positions.
+type LineIndex struct {
+	contents   string
+	lineStarts []int
+}
+
+func NewLineIndex(contents string) LineIndex {
+	lineStarts := []int{0}
+	for i := 0; i < len(contents); i++ {
+		if contents[i] == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return LineIndex{contents: contents, lineStarts: lineStarts}
+}
+
+func (index LineIndex) Position(offset int) Position {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > len(index.contents) {
+		offset = len(index.contents)
+	}
+	line := sort.Search(len(index.lineStarts), func(i int) bool {
+		return index.lineStarts[i] > offset
+	}) - 1
+	column := utf8.RuneCountInString(index.contents[index.lineStarts[line]:offset]) + 1
+	return Position{Line: line + 1, Column: column}
+}
+
+func (index LineIndex) Span(location Location) Span {
+	return Span{Start: index.Position(location.Start), End: index.Position(location.End)}
+}
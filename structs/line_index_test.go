@@ -0,0 +1,57 @@
+package structs
+
+import "testing"
+
+func TestLineIndex_Position(t *testing.T) {
+	contents := "\\bibitem{a}\nGa\u00ebl Le~Bec et al.\n\nlast"
+	tests := []struct {
+		name     string
+		offset   int
+		expected Position
+	}{
+		{
+			name:     "Start of file",
+			offset:   0,
+			expected: Position{Line: 1, Column: 1},
+		},
+		{
+			name:     "Newline belongs to the line it ends",
+			offset:   11,
+			expected: Position{Line: 1, Column: 12},
+		},
+		{
+			name:     "Start of second line",
+			offset:   12,
+			expected: Position{Line: 2, Column: 1},
+		},
+		{
+			name:     "Columns count runes not bytes",
+			offset:   17, // the space after "Gaël", which is 5 bytes but 4 runes
+			expected: Position{Line: 2, Column: 5},
+		},
+		{
+			name:     "Empty line",
+			offset:   32,
+			expected: Position{Line: 3, Column: 1},
+		},
+		{
+			name:     "End of file",
+			offset:   len(contents),
+			expected: Position{Line: 4, Column: 5},
+		},
+		{
+			name:     "Past end of file is clamped",
+			offset:   len(contents) + 10,
+			expected: Position{Line: 4, Column: 5},
+		},
+	}
+
+	index := NewLineIndex(contents)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := index.Position(tt.offset); got != tt.expected {
+				t.Errorf("Position() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
@@ -1,10 +1,31 @@
 package structs
 
+// Location is a range of bytes. File is only set once a location has been
+// resolved to the file it came from, see Contents.Resolve.
 type Location struct {
-	Start int `json:"start"`
-	End   int `json:"end"`
+	File  string `json:"file,omitempty"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
 }
 
 func LocationIn(needle Location, haystack Location) bool {
 	return needle.Start >= haystack.Start && needle.End <= haystack.End
 }
+
+// RuneLocation converts a range of runes in text, as reported by regexp2
+// matches, into a location in bytes.
+func RuneLocation(text string, runeIndex int, runeLength int) Location {
+	location := Location{Start: len(text), End: len(text)}
+	runes := 0
+	for i := range text {
+		if runes == runeIndex {
+			location.Start = i
+		}
+		if runes == runeIndex+runeLength {
+			location.End = i
+			return location
+		}
+		runes++
+	}
+	return location
+}
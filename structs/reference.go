@@ -0,0 +1,45 @@
+package structs
+
+// ReferenceField is part of a reference found by the reference parser, with
+// how confident the parser is, from 0 to 1, that it is the right text. The
+// confidence is 0 when the field was not found.
+type ReferenceField struct {
+	Value      string   `json:"value"`
+	Location   Location `json:"location"`
+	Confidence float64  `json:"confidence"`
+}
+
+func (f ReferenceField) Found() bool {
+	return f.Confidence > 0
+}
+
+// Author is one of the authors of a reference. Initials holds the given names
+// as written, which for JACoW references are initials such as "J. R.".
+// SurnameFirst is set for names written "Smith, J. R.".
+type Author struct {
+	Initials     string   `json:"initials"`
+	Surname      string   `json:"surname"`
+	SurnameFirst bool     `json:"surnameFirst"`
+	Location     Location `json:"location"`
+	Confidence   float64  `json:"confidence"`
+}
+
+// Reference is a bibitem split into its fields. AuthorList covers every
+// author, including any et al., and Journal is the name of the journal or, when
+// InProceedings is set, the proceedings.
+type Reference struct {
+	Authors       []Author       `json:"authors"`
+	AuthorList    ReferenceField `json:"authorList"`
+	EtAl          ReferenceField `json:"etAl"`
+	Title         ReferenceField `json:"title"`
+	Journal       ReferenceField `json:"journal"`
+	InProceedings bool           `json:"inProceedings"`
+	Volume        ReferenceField `json:"volume"`
+	Number        ReferenceField `json:"number"`
+	Pages         ReferenceField `json:"pages"`
+	Month         ReferenceField `json:"month"`
+	Year          ReferenceField `json:"year"`
+	Doi           ReferenceField `json:"doi"`
+	Url           ReferenceField `json:"url"`
+	ArXiv         ReferenceField `json:"arXiv"`
+}